@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	yoomodel "github.com/eclipsemode/go-yookassa-sdk/yookassa/model"
+	"github.com/imperatorofdwelling/payment-svc/internal/lib/fakeyookassa"
+	"github.com/imperatorofdwelling/payment-svc/pkg"
+	"github.com/imperatorofdwelling/payment-svc/pkg/logger"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// fakeyookassa serves a fake YooKassa API for environments that can not reach the sandbox:
+//
+//	go run ./cmd/fakeyookassa -addr :8090 -webhook http://localhost:8081/api/v1/logs/status
+//
+// Point pay_api.api_addr of the service at http://localhost:8090 and add the address the fake sends
+// webhooks from to webhook.allowed_ips. A single payment or payout can be given its own progression
+// with the fake_statuses metadata key, e.g. "pending,canceled".
+func main() {
+	defaults := fakeyookassa.DefaultOptions()
+
+	var (
+		addr          = flag.String("addr", ":8090", "address to listen on")
+		webhook       = flag.String("webhook", "", "URL notifications are sent to, none are sent if empty")
+		latency       = flag.Duration("latency", 0, "delay added to every response")
+		errorRate     = flag.Float64("error-rate", 0, "share of requests answered with 500, from 0 to 1")
+		statusDelay   = flag.Duration("status-delay", defaults.StatusDelay, "time an object spends in each status")
+		payments      = flag.String("payment-statuses", "pending,succeeded", "progression of payments with capture")
+		heldPayments  = flag.String("held-payment-statuses", "pending,waiting_for_capture", "progression of two-stage payments")
+		payouts       = flag.String("payout-statuses", "pending,succeeded", "progression of payouts")
+		refunds       = flag.String("refund-statuses", "pending,succeeded", "progression of refunds")
+		retryWebhooks = flag.Int("webhook-attempts", defaults.WebhookAttempts, "attempts to deliver a notification")
+	)
+
+	flag.Parse()
+
+	log := logger.NewZapLogger(pkg.LocalEnv)
+
+	opts := fakeyookassa.Options{
+		Latency:         *latency,
+		ErrorRate:       *errorRate,
+		StatusDelay:     *statusDelay,
+		WebhookURL:      *webhook,
+		WebhookAttempts: *retryWebhooks,
+	}
+
+	for _, p := range []struct {
+		value string
+		dst   *[]yoomodel.TransactionStatus
+	}{
+		{*payments, &opts.PaymentStatuses},
+		{*heldPayments, &opts.HeldPaymentStatuses},
+		{*payouts, &opts.PayoutStatuses},
+		{*refunds, &opts.RefundStatuses},
+	} {
+		statuses, err := fakeyookassa.ParseStatuses(p.value)
+		if err != nil {
+			log.Fatal(err)
+		}
+		*p.dst = statuses
+	}
+
+	fake := fakeyookassa.New(opts, log.Named("fake_yookassa"))
+
+	srv := &http.Server{
+		Addr:        *addr,
+		Handler:     fake,
+		IdleTimeout: 60 * time.Second,
+	}
+
+	go func() {
+		log.Infof("fake YooKassa listening on %s", *addr)
+
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal(err)
+		}
+	}()
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+	<-stop
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := srv.Shutdown(ctx); err != nil {
+		log.Error(err)
+	}
+
+	fake.Close()
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	yoomodel "github.com/eclipsemode/go-yookassa-sdk/yookassa/model"
+	"github.com/imperatorofdwelling/payment-svc/internal/config"
+	"github.com/imperatorofdwelling/payment-svc/internal/domain/model"
+	"github.com/imperatorofdwelling/payment-svc/internal/lib/export"
+	"github.com/imperatorofdwelling/payment-svc/internal/service"
+	"github.com/imperatorofdwelling/payment-svc/internal/storage/postgres"
+	"github.com/imperatorofdwelling/payment-svc/pkg/logger"
+	"io"
+	"os"
+	"time"
+)
+
+// logexport dumps transaction logs created in [from, to) to a file or stdout:
+//
+//	go run ./cmd/logexport -env local -month 2025-01 -format csv -out logs-2025-01.csv
+//	go run ./cmd/logexport -env local -from 2025-01-01 -to 2025-01-15 -format jsonl
+func main() {
+	var (
+		month    = flag.String("month", "", "month to export, YYYY-MM (overrides -from and -to)")
+		from     = flag.String("from", "", "start of the range, YYYY-MM-DD or RFC 3339, inclusive")
+		to       = flag.String("to", "", "end of the range, YYYY-MM-DD or RFC 3339, exclusive")
+		format   = flag.String("format", string(export.CSV), "output format: csv or jsonl")
+		out      = flag.String("out", "", "output file, stdout if empty")
+		txType   = flag.String("type", "", "transaction type filter")
+		status   = flag.String("status", "", "transaction status filter")
+		currency = flag.String("currency", "", "currency filter")
+	)
+
+	cfg := config.MustLoad()
+
+	log := logger.NewZapLogger(cfg.Env)
+
+	exportFormat, err := export.ParseFormat(*format)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	filter := model.LogFilter{
+		TransactionType: yoomodel.TransactionType(*txType),
+		Status:          yoomodel.TransactionStatus(*status),
+		Currency:        yoomodel.Currency(*currency),
+		Order:           model.SortAsc,
+	}
+
+	filter.CreatedFrom, filter.CreatedTo, err = parseRange(*month, *from, *to)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	db, err := postgres.NewPsqlStorage(cfg.Db.Postgres)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	var w io.Writer = os.Stdout
+
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer f.Close()
+
+		w = f
+	}
+
+	writer, err := export.NewLogsWriter(w, exportFormat)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	logsSvc := service.NewLogsService(postgres.NewLogsRepo(db, log.Named("logs_repo")), log.Named("logs_service"))
+
+	var count int
+
+	err = logsSvc.ExportLogs(context.Background(), filter, func(l model.Log) error {
+		count++
+		return writer.Write(l)
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := writer.Flush(); err != nil {
+		log.Fatal(err)
+	}
+
+	log.Infof("exported %d logs", count)
+}
+
+func parseRange(month, from, to string) (*time.Time, *time.Time, error) {
+	if month != "" {
+		start, err := time.Parse("2006-01", month)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid month: %w", err)
+		}
+
+		end := start.AddDate(0, 1, 0)
+
+		return &start, &end, nil
+	}
+
+	start, err := parseDate(from)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid from: %w", err)
+	}
+
+	end, err := parseDate(to)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid to: %w", err)
+	}
+
+	return start, end, nil
+}
+
+func parseDate(s string) (*time.Time, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	t, err := time.Parse(time.DateOnly, s)
+	if err != nil {
+		t, err = time.Parse(time.RFC3339, s)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &t, nil
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"github.com/imperatorofdwelling/payment-svc/internal/config"
+	"github.com/imperatorofdwelling/payment-svc/internal/gateway"
+	"github.com/imperatorofdwelling/payment-svc/internal/service"
+	"github.com/imperatorofdwelling/payment-svc/internal/storage/postgres"
+	"github.com/imperatorofdwelling/payment-svc/pkg/logger"
+	"os"
+	"time"
+)
+
+// reconcile compares one UTC day of payments and payouts with YooKassa and stores the report:
+//
+//	go run ./cmd/reconcile -env local -date 2025-01-31
+//
+// Without -date the previous day is checked. The exit code is 2 when discrepancies were found.
+func main() {
+	date := flag.String("date", "", "day to reconcile, YYYY-MM-DD, yesterday if empty")
+
+	cfg := config.MustLoad()
+
+	log := logger.NewZapLogger(cfg.Env)
+
+	day := time.Now().AddDate(0, 0, -1)
+
+	if *date != "" {
+		var err error
+
+		day, err = time.Parse(time.DateOnly, *date)
+		if err != nil {
+			log.Fatalf("invalid date: %v", err)
+		}
+	}
+
+	db, err := postgres.NewPsqlStorage(cfg.Db.Postgres)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	paymentGateway, payoutGateway, err := gateway.New(cfg, log.Named("gateway"))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	logsSvc := service.NewLogsService(postgres.NewLogsRepo(db, log.Named("logs_repo")), log.Named("logs_service"))
+	reconciliationRepo := postgres.NewReconciliationRepo(db, log.Named("reconciliation_repo"))
+	reconciliationSvc := service.NewReconciliationService(reconciliationRepo, logsSvc, paymentGateway, payoutGateway, log.Named("reconciliation_service"))
+
+	report, err := reconciliationSvc.Reconcile(context.Background(), day)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for _, d := range report.Discrepancies {
+		log.Warnf("%s %s %s: local %s %s %s, upstream %s %s %s", d.Kind, d.TransactionType, d.TransactionID,
+			d.LocalStatus, d.LocalValue, d.LocalCurrency, d.UpstreamStatus, d.UpstreamValue, d.UpstreamCurrency)
+	}
+
+	if len(report.Discrepancies) > 0 {
+		os.Exit(2)
+	}
+}
@@ -1,11 +1,15 @@
 package app
 
 import (
+	"context"
 	"github.com/imperatorofdwelling/payment-svc/internal/config"
 	"github.com/imperatorofdwelling/payment-svc/internal/handler/http"
 	"github.com/imperatorofdwelling/payment-svc/internal/lib/scheduler"
 	v10 "github.com/imperatorofdwelling/payment-svc/internal/lib/validator"
+	"github.com/imperatorofdwelling/payment-svc/internal/service"
 	"github.com/imperatorofdwelling/payment-svc/internal/storage"
+	"github.com/imperatorofdwelling/payment-svc/internal/storage/postgres"
+	"github.com/imperatorofdwelling/payment-svc/internal/storage/redis"
 	"github.com/imperatorofdwelling/payment-svc/pkg/logger"
 )
 
@@ -13,6 +17,8 @@ type App struct {
 	Server *http.Server
 
 	Scheduler *scheduler.Scheduler
+
+	PayoutSubscriber *service.PayoutSubscriber
 }
 
 func NewApp() *App {
@@ -20,20 +26,30 @@ func NewApp() *App {
 
 	log := logger.NewZapLogger(cfg.Env)
 
-	s := scheduler.NewScheduler(log)
-	s.Start()
-
-	v10.NewValidator(log)
+	v10.NewValidator(cfg.Currencies, log)
 
 	storages := storage.GetStorages(cfg, log)
 
-	router := http.NewRouter(storages, log, cfg)
+	jobsRepo := postgres.NewJobsRepo(storages.Psql, log.Named("jobs_repo"))
+	locksRepo := redis.NewLockRepo(storages.Redis)
+	s := scheduler.NewScheduler(jobsRepo, locksRepo, log.Named("scheduler"))
+
+	router := http.NewRouter(storages, s, log, cfg)
+
+	// job handlers are registered by the router, so persisted jobs can only be restored after it
+	if err := s.Restore(context.Background()); err != nil {
+		log.Fatalf("failed to restore scheduled jobs: %v", err)
+	}
+	s.Start()
+
+	router.PayoutSubscriber.Start()
 
 	server := http.NewServer(cfg.Server, router.Handler, log)
 
 	app := &App{
-		Server:    server,
-		Scheduler: s,
+		Server:           server,
+		Scheduler:        s,
+		PayoutSubscriber: router.PayoutSubscriber,
 	}
 
 	return app
@@ -11,24 +11,91 @@ import (
 )
 
 type Config struct {
-	Env    pkg.Env `yaml:"env" env-default:"local" env-required:"true"`
-	Server `yaml:"server" env-required:"true"`
-	Db     `yaml:"db" env-required:"true"`
-	PayApi `yaml:"pay_api"`
+	Env            pkg.Env `yaml:"env" env-default:"local" env-required:"true"`
+	Server         `yaml:"server" env-required:"true"`
+	Db             `yaml:"db" env-required:"true"`
+	Gateway        `yaml:"gateway"`
+	PayApi         `yaml:"pay_api"`
+	Webhook        `yaml:"webhook"`
+	Reconciliation `yaml:"reconciliation"`
+	Currencies     `yaml:"currencies"`
+	Idempotency    `yaml:"idempotency"`
+	StatusSync     `yaml:"status_sync"`
 }
 
+// StatusSync configures polling YooKassa for payouts until their status is final. Workers poll due payouts
+// concurrently, a payout is polled again after Backoff doubled with every attempt up to MaxBackoff and given up
+// on GiveUpAfter it was subscribed. A payout claimed by an instance that died is polled again once Lease is over,
+// so Lease must outlast a call to YooKassa with all its retries.
+type StatusSync struct {
+	Workers      int           `yaml:"workers" env-default:"4"`
+	PollInterval time.Duration `yaml:"poll_interval" env-default:"1s"`
+	Backoff      time.Duration `yaml:"backoff" env-default:"1s"`
+	MaxBackoff   time.Duration `yaml:"max_backoff" env-default:"5m"`
+	Lease        time.Duration `yaml:"lease" env-default:"2m"`
+	GiveUpAfter  time.Duration `yaml:"give_up_after" env-default:"72h"`
+}
+
+// Idempotency configures how long responses to requests with an Idempotence-Key are kept for replay.
+// A request that is still being handled holds its key for InProgressTTL at most, so the key is freed
+// when the instance handling it dies. It must clearly outlast the 60s request timeout, a key freed while
+// its request is still running lets a duplicate through.
+type Idempotency struct {
+	TTL           time.Duration `yaml:"ttl" env-default:"24h"`
+	InProgressTTL time.Duration `yaml:"in_progress_ttl" env-default:"5m"`
+}
+
+// Currencies lists the ISO 4217 codes accepted per operation. Payments and payouts are enabled separately
+// in the YooKassa shop, so a currency can be accepted from payers before hosts can be paid out in it.
+type Currencies struct {
+	Payments []string `yaml:"payments" env-default:"RUB"`
+	Payouts  []string `yaml:"payouts" env-default:"RUB"`
+}
+
+// Reconciliation configures the daily comparison of stored transactions with YooKassa.
+// Schedule is a standard 5-field cron expression, each run checks the previous UTC day.
+type Reconciliation struct {
+	Schedule string `yaml:"schedule" env-default:"0 3 * * *"`
+}
+
+// Webhook configures the YooKassa notification receiver. AllowedIPs accepts plain addresses and CIDR ranges,
+// the defaults are the ranges YooKassa sends notifications from. X-Forwarded-For and X-Real-IP are only
+// read from requests made by TrustedProxies, which take the same format and are empty by default.
+type Webhook struct {
+	AllowedIPs     []string      `yaml:"allowed_ips" env-default:"185.71.76.0/27,185.71.77.0/27,77.75.153.0/25,77.75.156.11,77.75.156.35,77.75.154.128/25,2a02:5180::/32"`
+	TrustedProxies []string      `yaml:"trusted_proxies"`
+	DedupTTL       time.Duration `yaml:"dedup_ttl" env-default:"24h"`
+}
+
+// Gateway chooses the payment provider, PayApi holds the YooKassa credentials.
+type Gateway struct {
+	Provider string `yaml:"provider" env-default:"yookassa"`
+}
+
+// PayApi configures the YooKassa client. Timeout bounds every attempt of a call, failed attempts are retried
+// MaxRetries times with backoff starting at RetryBackoff. BreakerThreshold failures in a row stop the calls
+// for BreakerCooldown.
 type PayApi struct {
-	ShopID          int    `yaml:"shop_id" env-required:"true"`
-	SecretKey       string `yaml:"secret_key" env-required:"true"`
-	PayoutAgentID   int    `yaml:"payout_agent_id" env-required:"true"`
-	PayoutSecretKey string `yaml:"payout_secret_key" env-required:"true"`
+	ShopID           int           `yaml:"shop_id" env-required:"true"`
+	SecretKey        string        `yaml:"secret_key" env-required:"true"`
+	PayoutAgentID    int           `yaml:"payout_agent_id" env-required:"true"`
+	PayoutSecretKey  string        `yaml:"payout_secret_key" env-required:"true"`
+	ApiAddr          string        `yaml:"api_addr" env-default:"https://api.yookassa.ru/v3"`
+	Timeout          time.Duration `yaml:"timeout" env-default:"10s"`
+	MaxRetries       int           `yaml:"max_retries" env-default:"3"`
+	RetryBackoff     time.Duration `yaml:"retry_backoff" env-default:"200ms"`
+	BreakerThreshold int           `yaml:"breaker_threshold" env-default:"5"`
+	BreakerCooldown  time.Duration `yaml:"breaker_cooldown" env-default:"30s"`
 }
 
+// Server configures the public API listener. DebugAddr is a separate listener serving /debug/vars,
+// it is bound to localhost by default and disabled when empty.
 type Server struct {
 	Host        string        `yaml:"host" env-default:"localhost" env-required:"true"`
 	Port        int           `yaml:"port" env-default:"8080" env-required:"true"`
 	Timeout     time.Duration `yaml:"timeout" env-default:"4s"`
 	IdleTimeout time.Duration `yaml:"idle_timeout" env-default:"60s"`
+	DebugAddr   string        `yaml:"debug_addr" env-default:"localhost:6060"`
 }
 
 type Db struct {
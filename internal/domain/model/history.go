@@ -0,0 +1,27 @@
+package model
+
+import (
+	yoomodel "github.com/eclipsemode/go-yookassa-sdk/yookassa/model"
+	"github.com/google/uuid"
+	"time"
+)
+
+// StatusSource tells where a transaction status change came from.
+type StatusSource string
+
+const (
+	WebhookSource   StatusSource = "webhook"
+	PollerSource    StatusSource = "poller"
+	ApiSource       StatusSource = "api"
+	KafkaSource     StatusSource = "kafka"
+	SchedulerSource StatusSource = "scheduler"
+)
+
+type StatusHistory struct {
+	ID            uuid.UUID                  `json:"id"`
+	TransactionID string                     `json:"transaction_id"`
+	OldStatus     yoomodel.TransactionStatus `json:"old_status,omitempty"`
+	NewStatus     yoomodel.TransactionStatus `json:"new_status"`
+	Source        StatusSource               `json:"source"`
+	CreatedAt     time.Time                  `json:"created_at"`
+}
@@ -0,0 +1,20 @@
+package model
+
+// IdempotentRequest is what is kept per Idempotence-Key: the fingerprint of the first request with the key
+// and, once it is handled, the response it got. A request without a response is still in progress and
+// Owner identifies the handling of it, so a request that outlived its key does not touch the next one.
+type IdempotentRequest struct {
+	Fingerprint string              `json:"fingerprint"`
+	Owner       string              `json:"owner,omitempty"`
+	Response    *IdempotentResponse `json:"response,omitempty"`
+}
+
+type IdempotentResponse struct {
+	StatusCode  int    `json:"status_code"`
+	ContentType string `json:"content_type,omitempty"`
+	Body        []byte `json:"body"`
+}
+
+func (r *IdempotentRequest) IsCompleted() bool {
+	return r.Response != nil
+}
@@ -0,0 +1,29 @@
+package model
+
+import (
+	"encoding/json"
+	"github.com/google/uuid"
+	"time"
+)
+
+type JobStatus string
+
+const (
+	JobPending  JobStatus = "pending"
+	JobDone     JobStatus = "done"
+	JobFailed   JobStatus = "failed"
+	JobCanceled JobStatus = "canceled"
+)
+
+// ScheduledJob is a one-shot job stored in Postgres so that it survives restarts.
+// Kind selects the handler registered in the scheduler, Payload is passed to it as is.
+type ScheduledJob struct {
+	ID        uuid.UUID       `json:"id"`
+	Kind      string          `json:"kind"`
+	Payload   json.RawMessage `json:"payload"`
+	RunAt     time.Time       `json:"run_at"`
+	Status    JobStatus       `json:"status"`
+	LastError string          `json:"last_error,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
@@ -0,0 +1,64 @@
+package model
+
+import (
+	"errors"
+	yoomodel "github.com/eclipsemode/go-yookassa-sdk/yookassa/model"
+	"github.com/google/uuid"
+)
+
+var ErrInvalidPostingAmount = errors.New("invalid posting amount")
+
+// PostingKind tells which transaction event a posting records. A (kind, reference) pair is posted once.
+type PostingKind string
+
+const (
+	PaymentPosting        PostingKind = "payment"
+	RefundPosting         PostingKind = "refund"
+	PayoutPosting         PostingKind = "payout"
+	PayoutReversalPosting PostingKind = "payout_reversal"
+)
+
+type AccountType string
+
+const (
+	UserAccount AccountType = "user"
+	// ClearingAccount is the counterpart of every user entry: money of the shop held by YooKassa.
+	// There is one clearing account per currency.
+	ClearingAccount AccountType = "clearing"
+)
+
+// Posting is a balanced set of ledger entries: the signed values of its entries sum to zero.
+type Posting struct {
+	Kind        PostingKind
+	ReferenceID string
+	Currency    yoomodel.Currency
+	Entries     []PostingEntry
+}
+
+type PostingEntry struct {
+	Account AccountType
+	UserID  *uuid.UUID
+	Value   Money
+}
+
+// NewUserPosting moves the amount between the clearing account and the user account. A credit increases
+// the user balance, a debit decreases it.
+func NewUserPosting(kind PostingKind, referenceID string, userID uuid.UUID, amount Money, credit bool) (*Posting, error) {
+	if amount.IsZero() || amount.IsNegative() {
+		return nil, ErrInvalidPostingAmount
+	}
+
+	if !credit {
+		amount = amount.Neg()
+	}
+
+	return &Posting{
+		Kind:        kind,
+		ReferenceID: referenceID,
+		Currency:    amount.Currency,
+		Entries: []PostingEntry{
+			{Account: UserAccount, UserID: &userID, Value: amount},
+			{Account: ClearingAccount, Value: amount.Neg()},
+		},
+	}, nil
+}
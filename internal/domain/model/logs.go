@@ -11,8 +11,57 @@ type Log struct {
 	TransactionID   string                     `json:"transaction_id" validate:"required"`
 	TransactionType yoomodel.TransactionType   `json:"transaction_type" validate:"required"`
 	Status          yoomodel.TransactionStatus `json:"status" validate:"required"`
-	Value           string                     `json:"value" validate:"required,money"`
-	Currency        yoomodel.Currency          `json:"currency" validate:"required,iso4217"`
+	Amount          Money                      `json:"amount"`
 	CreatedAt       time.Time                  `json:"created_at"`
 	UpdatedAt       time.Time                  `json:"updated_at"`
 }
+
+// statusTransitions describes the transaction lifecycle: pending → waiting_for_capture → succeeded/canceled.
+// Refunds and payouts skip waiting_for_capture. Final statuses have no outgoing transitions.
+var statusTransitions = map[yoomodel.TransactionStatus][]yoomodel.TransactionStatus{
+	yoomodel.Pending:           {yoomodel.WaitingForCapture, yoomodel.Succeeded, yoomodel.Canceled},
+	yoomodel.WaitingForCapture: {yoomodel.Succeeded, yoomodel.Canceled},
+}
+
+// IsFinalStatus reports whether the transaction status can no longer change.
+func IsFinalStatus(status yoomodel.TransactionStatus) bool {
+	return status == yoomodel.Succeeded || status == yoomodel.Canceled
+}
+
+// CanTransition reports whether a transaction may move from one status to another.
+func CanTransition(from, to yoomodel.TransactionStatus) bool {
+	for _, next := range statusTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+
+	return false
+}
+
+type SortOrder string
+
+const (
+	SortAsc  SortOrder = "asc"
+	SortDesc SortOrder = "desc"
+)
+
+// LogFilter narrows down the logs query. Empty fields are not applied.
+// The json names are the query parameters, validation errors refer to them.
+type LogFilter struct {
+	TransactionType yoomodel.TransactionType   `json:"type" validate:"omitempty,oneof=payment refund payout deal"`
+	Status          yoomodel.TransactionStatus `json:"status" validate:"omitempty,oneof=pending waiting_for_capture succeeded canceled"`
+	Currency        yoomodel.Currency          `json:"currency" validate:"omitempty,iso4217"`
+	AmountFrom      string                     `json:"amount_from" validate:"omitempty,money"`
+	AmountTo        string                     `json:"amount_to" validate:"omitempty,money"`
+	CreatedFrom     *time.Time                 `json:"created_from"`
+	CreatedTo       *time.Time                 `json:"created_to"`
+	Order           SortOrder                  `json:"order" validate:"required,oneof=asc desc"`
+	Limit           int                        `json:"limit" validate:"min=1,max=500"`
+	Cursor          *Cursor                    `json:"cursor"`
+}
+
+type LogsPage struct {
+	Items      []Log  `json:"items"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
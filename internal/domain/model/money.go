@@ -0,0 +1,216 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	yoomodel "github.com/eclipsemode/go-yookassa-sdk/yookassa/model"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrInvalidMoney          = errors.New("invalid money amount")
+	ErrMoneyCurrencyMismatch = errors.New("money currencies do not match")
+	ErrMoneyOverflow         = errors.New("money amount overflows")
+)
+
+// currencyExponents lists ISO 4217 currencies whose minor unit is not a hundredth.
+var currencyExponents = map[yoomodel.Currency]int{
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0, "KRW": 0, "PYG": 0,
+	"RWF": 0, "UGX": 0, "UYI": 0, "VND": 0, "VUV": 0, "XAF": 0, "XOF": 0, "XPF": 0,
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+}
+
+// CurrencyExponent returns the number of decimal places of the currency minor unit.
+func CurrencyExponent(currency yoomodel.Currency) int {
+	if exp, ok := currencyExponents[currency]; ok {
+		return exp
+	}
+
+	return 2
+}
+
+// Money is an exact amount in the minor units of its currency: kopecks for RUB, yen for JPY.
+// In JSON it looks like the YooKassa amount object, in SQL it is written as a decimal string.
+type Money struct {
+	Minor    int64
+	Currency yoomodel.Currency `validate:"required,iso4217"`
+}
+
+func NewMoney(minor int64, currency yoomodel.Currency) Money {
+	return Money{Minor: minor, Currency: currency}
+}
+
+// ParseMoney parses a decimal amount like "10.5" or "-100.00". Digits beyond the currency exponent
+// are only accepted when they are zeros, so "100.000" is a valid RUB amount but "100.001" is not.
+func ParseMoney(value string, currency yoomodel.Currency) (Money, error) {
+	exp := CurrencyExponent(currency)
+
+	digits, negative := strings.CutPrefix(value, "-")
+
+	intPart, fracPart, _ := strings.Cut(digits, ".")
+	if intPart == "" || !isDigits(intPart) || !isDigits(fracPart) {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidMoney, value)
+	}
+
+	if len(fracPart) > exp {
+		if strings.Trim(fracPart[exp:], "0") != "" {
+			return Money{}, fmt.Errorf("%w: %q has more than %d decimal places for %s", ErrInvalidMoney, value, exp, currency)
+		}
+		fracPart = fracPart[:exp]
+	}
+
+	fracPart += strings.Repeat("0", exp-len(fracPart))
+
+	minor, err := strconv.ParseInt(intPart+fracPart, 10, 64)
+	if err != nil {
+		return Money{}, fmt.Errorf("%w: %q", ErrMoneyOverflow, value)
+	}
+
+	if negative {
+		minor = -minor
+	}
+
+	return Money{Minor: minor, Currency: currency}, nil
+}
+
+// Amount is a decimal amount as clients write it in request bodies. It is validated with the money rule
+// and only then parsed, so a malformed value is reported as a field error instead of a decoding error.
+type Amount struct {
+	Value    string            `json:"value" validate:"required,money"`
+	Currency yoomodel.Currency `json:"currency" validate:"required,iso4217"`
+}
+
+func (a Amount) Money() (Money, error) {
+	return ParseMoney(a.Value, a.Currency)
+}
+
+// MoneyFromAmount converts the YooKassa amount object.
+func MoneyFromAmount(amount yoomodel.Amount) (Money, error) {
+	return ParseMoney(amount.Value, amount.Currency)
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Decimal formats the amount with exactly as many decimal places as the currency has.
+func (m Money) Decimal() string {
+	exp := CurrencyExponent(m.Currency)
+
+	abs := uint64(m.Minor)
+	sign := ""
+	if m.Minor < 0 {
+		abs = -abs
+		sign = "-"
+	}
+
+	s := strconv.FormatUint(abs, 10)
+	if exp == 0 {
+		return sign + s
+	}
+
+	if len(s) <= exp {
+		s = strings.Repeat("0", exp-len(s)+1) + s
+	}
+
+	return sign + s[:len(s)-exp] + "." + s[len(s)-exp:]
+}
+
+func (m Money) String() string {
+	return m.Decimal() + " " + string(m.Currency)
+}
+
+// ToAmount converts the money to the YooKassa amount object.
+func (m Money) ToAmount() yoomodel.Amount {
+	return yoomodel.Amount{Value: m.Decimal(), Currency: m.Currency}
+}
+
+func (m Money) IsZero() bool {
+	return m.Minor == 0
+}
+
+func (m Money) IsNegative() bool {
+	return m.Minor < 0
+}
+
+func (m Money) Neg() Money {
+	return Money{Minor: -m.Minor, Currency: m.Currency}
+}
+
+func (m Money) Add(o Money) (Money, error) {
+	if m.Currency != o.Currency {
+		return Money{}, fmt.Errorf("%w: %s and %s", ErrMoneyCurrencyMismatch, m.Currency, o.Currency)
+	}
+
+	if (o.Minor > 0 && m.Minor > math.MaxInt64-o.Minor) || (o.Minor < 0 && m.Minor < math.MinInt64-o.Minor) {
+		return Money{}, ErrMoneyOverflow
+	}
+
+	return Money{Minor: m.Minor + o.Minor, Currency: m.Currency}, nil
+}
+
+func (m Money) Sub(o Money) (Money, error) {
+	if o.Minor == math.MinInt64 {
+		return Money{}, ErrMoneyOverflow
+	}
+
+	return m.Add(o.Neg())
+}
+
+// Cmp compares two amounts of the same currency and returns -1, 0 or +1.
+func (m Money) Cmp(o Money) (int, error) {
+	if m.Currency != o.Currency {
+		return 0, fmt.Errorf("%w: %s and %s", ErrMoneyCurrencyMismatch, m.Currency, o.Currency)
+	}
+
+	switch {
+	case m.Minor < o.Minor:
+		return -1, nil
+	case m.Minor > o.Minor:
+		return 1, nil
+	default:
+		return 0, nil
+	}
+}
+
+type moneyJSON struct {
+	Value    string            `json:"value"`
+	Currency yoomodel.Currency `json:"currency"`
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(moneyJSON{Value: m.Decimal(), Currency: m.Currency})
+}
+
+func (m *Money) UnmarshalJSON(data []byte) error {
+	var raw moneyJSON
+
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	parsed, err := ParseMoney(raw.Value, raw.Currency)
+	if err != nil {
+		return err
+	}
+
+	*m = parsed
+
+	return nil
+}
+
+// Value writes the amount into a numeric column. The currency is a separate column, so reading goes
+// through ParseMoney with both.
+func (m Money) Value() (driver.Value, error) {
+	return m.Decimal(), nil
+}
@@ -0,0 +1,42 @@
+package model
+
+import (
+	yoomodel "github.com/eclipsemode/go-yookassa-sdk/yookassa/model"
+	"strings"
+)
+
+type NotificationEvent string
+
+const (
+	PaymentSucceededEvent         NotificationEvent = "payment.succeeded"
+	PaymentWaitingForCaptureEvent NotificationEvent = "payment.waiting_for_capture"
+	PaymentCanceledEvent          NotificationEvent = "payment.canceled"
+	RefundSucceededEvent          NotificationEvent = "refund.succeeded"
+	PayoutSucceededEvent          NotificationEvent = "payout.succeeded"
+	PayoutCanceledEvent           NotificationEvent = "payout.canceled"
+)
+
+// Notification is the webhook body sent by YooKassa. Only the object id is read from it,
+// the object itself is always re-fetched from the API before being trusted.
+type Notification struct {
+	Type   string             `json:"type" validate:"required,eq=notification"`
+	Event  NotificationEvent  `json:"event" validate:"required,oneof=payment.succeeded payment.waiting_for_capture payment.canceled refund.succeeded payout.succeeded payout.canceled"`
+	Object NotificationObject `json:"object" validate:"required"`
+}
+
+type NotificationObject struct {
+	ID     string                     `json:"id" validate:"required"`
+	Status yoomodel.TransactionStatus `json:"status"`
+}
+
+// TransactionType returns the kind of object the event is about, e.g. payment for payment.succeeded.
+func (e NotificationEvent) TransactionType() yoomodel.TransactionType {
+	objectType, _, _ := strings.Cut(string(e), ".")
+	return yoomodel.TransactionType(objectType)
+}
+
+// Status returns the object status announced by the event, e.g. succeeded for payment.succeeded.
+func (e NotificationEvent) Status() yoomodel.TransactionStatus {
+	_, status, _ := strings.Cut(string(e), ".")
+	return yoomodel.TransactionStatus(status)
+}
@@ -0,0 +1,79 @@
+package model
+
+import (
+	yoomodel "github.com/eclipsemode/go-yookassa-sdk/yookassa/model"
+	"github.com/google/uuid"
+	"time"
+)
+
+type Payment struct {
+	ID                uuid.UUID                  `json:"id"`
+	PaymentID         string                     `json:"payment_id" validate:"required"`
+	Status            yoomodel.TransactionStatus `json:"status" validate:"required"`
+	Amount            Money                      `json:"amount"`
+	Description       string                     `json:"description,omitempty"`
+	Metadata          any                        `json:"metadata,omitempty"`
+	ConfirmationType  yoomodel.ConfirmationType  `json:"confirmation_type,omitempty"`
+	ConfirmationURL   string                     `json:"confirmation_url,omitempty"`
+	ConfirmationToken string                     `json:"confirmation_token,omitempty"`
+	PaymentMethodType yoomodel.PaymentMethodType `json:"payment_method_type,omitempty"`
+	Paid              bool                       `json:"paid"`
+	Refundable        bool                       `json:"refundable"`
+	Capture           bool                       `json:"capture"`
+	Test              bool                       `json:"test"`
+	CaptureDeadline   *time.Time                 `json:"capture_deadline,omitempty"`
+	CapturedAt        *time.Time                 `json:"captured_at,omitempty"`
+	IdempotenceKey    string                     `json:"idempotence_key"`
+	CreatedAt         time.Time                  `json:"created_at"`
+	UpdatedAt         time.Time                  `json:"updated_at"`
+}
+
+// PaymentRequest is the body of a new payment. Field names follow the YooKassa API, so a request written
+// for YooKassa is accepted as it is as long as it only uses these fields.
+type PaymentRequest struct {
+	Amount            Amount             `json:"amount" validate:"required"`
+	Description       string             `json:"description,omitempty" validate:"omitempty,max=128"`
+	Capture           bool               `json:"capture"`
+	Confirmation      *Confirmation      `json:"confirmation,omitempty" validate:"omitempty"`
+	PaymentMethodData *PaymentMethodData `json:"payment_method_data,omitempty" validate:"omitempty,excluded_with=PaymentMethodID PaymentToken"`
+	PaymentMethodID   string             `json:"payment_method_id,omitempty" validate:"omitempty,excluded_with=PaymentToken"`
+	PaymentToken      string             `json:"payment_token,omitempty"`
+	SavePaymentMethod bool               `json:"save_payment_method,omitempty"`
+	ClientIP          string             `json:"client_ip,omitempty" validate:"omitempty,ip"`
+	Metadata          map[string]string  `json:"metadata,omitempty" validate:"omitempty,max=16"`
+}
+
+// Confirmation tells how the payer confirms the payment. ReturnURL is where the payer comes back to
+// after a redirect or a mobile application.
+type Confirmation struct {
+	Type      yoomodel.ConfirmationType `json:"type" validate:"required,oneof=redirect embedded external qr mobile_application"`
+	ReturnURL string                    `json:"return_url,omitempty" validate:"required_if_field=Type redirect mobile_application,omitempty,url"`
+	Locale    string                    `json:"locale,omitempty" validate:"omitempty,oneof=ru_RU en_US"`
+	Enforce   bool                      `json:"enforce,omitempty" validate:"forbidden_unless=Type redirect"`
+}
+
+// PaymentMethodData chooses the payment method up front, otherwise the payer chooses it at the provider.
+type PaymentMethodData struct {
+	Type  yoomodel.PaymentMethodType `json:"type" validate:"required"`
+	Phone string                     `json:"phone,omitempty" validate:"required_if_field=Type mobile_balance,omitempty,e164"`
+}
+
+// UserID returns the host the payment was made for, passed by the main service in metadata user_id.
+// Payments without it are not credited to anyone.
+func (p *Payment) UserID() (uuid.UUID, bool) {
+	var raw string
+
+	switch m := p.Metadata.(type) {
+	case map[string]any:
+		raw, _ = m["user_id"].(string)
+	case map[string]string:
+		raw = m["user_id"]
+	}
+
+	id, err := uuid.Parse(raw)
+	if err != nil {
+		return uuid.Nil, false
+	}
+
+	return id, true
+}
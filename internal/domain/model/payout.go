@@ -0,0 +1,46 @@
+package model
+
+import (
+	yoomodel "github.com/eclipsemode/go-yookassa-sdk/yookassa/model"
+	"github.com/google/uuid"
+	"time"
+)
+
+type Payout struct {
+	ID            uuid.UUID                  `json:"id"`
+	PayoutID      string                     `json:"payout_id" validate:"required"`
+	Status        yoomodel.TransactionStatus `json:"status" validate:"required"`
+	Value         string                     `json:"value" validate:"required,money"`
+	Currency      yoomodel.Currency          `json:"currency" validate:"required,iso4217"`
+	Description   string                     `json:"description,omitempty"`
+	Metadata      any                        `json:"metadata,omitempty"`
+	CardID        *uuid.UUID                 `json:"card_id,omitempty"`
+	PayoutToken   string                     `json:"payout_token,omitempty"`
+	UserID        *uuid.UUID                 `json:"user_id,omitempty"`
+	Test          bool                       `json:"test"`
+	StatusHistory []StatusHistory            `json:"status_history,omitempty"`
+	CreatedAt     time.Time                  `json:"created_at"`
+	UpdatedAt     time.Time                  `json:"updated_at"`
+}
+
+// PayoutRequest is the body of a new payout. The money goes either to a saved card by its payout token
+// or to the destination given in the request.
+type PayoutRequest struct {
+	Amount            Amount             `json:"amount" validate:"required"`
+	PayoutToken       string             `json:"payout_token,omitempty" validate:"required_without=PayoutDestination,excluded_with=PayoutDestination"`
+	PayoutDestination *PayoutDestination `json:"payout_destination,omitempty" validate:"omitempty"`
+	Description       string             `json:"description,omitempty" validate:"omitempty,max=128"`
+	Metadata          map[string]string  `json:"metadata,omitempty" validate:"omitempty,max=16"`
+}
+
+type PayoutDestination struct {
+	Type          yoomodel.PaymentMethodType `json:"type" validate:"required,oneof=bank_card sbp yoo_money"`
+	Card          *PayoutCard                `json:"card,omitempty" validate:"required_if_field=Type bank_card,forbidden_unless=Type bank_card"`
+	BankID        string                     `json:"bank_id,omitempty" validate:"required_if_field=Type sbp,forbidden_unless=Type sbp"`
+	Phone         string                     `json:"phone,omitempty" validate:"required_if_field=Type sbp,forbidden_unless=Type sbp,omitempty,e164"`
+	AccountNumber string                     `json:"account_number,omitempty" validate:"required_if_field=Type yoo_money,forbidden_unless=Type yoo_money,omitempty,numeric,min=11,max=33"`
+}
+
+type PayoutCard struct {
+	Number string `json:"number" validate:"required,credit_card"`
+}
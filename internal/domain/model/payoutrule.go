@@ -0,0 +1,60 @@
+package model
+
+import (
+	yoomodel "github.com/eclipsemode/go-yookassa-sdk/yookassa/model"
+	"github.com/google/uuid"
+	"time"
+)
+
+type PayoutRulePeriod string
+
+const (
+	WeeklyPayoutRule  PayoutRulePeriod = "weekly"
+	MonthlyPayoutRule PayoutRulePeriod = "monthly"
+)
+
+// PayoutRuleHour is the UTC hour recurring payouts are made at.
+const PayoutRuleHour = 9
+
+// PayoutRule sweeps the available balance of a user to a saved card every week or month,
+// as long as the balance reaches MinAmount. Weekday is 0 for Sunday, DayOfMonth is capped at 28
+// so that every month has it.
+type PayoutRule struct {
+	ID         uuid.UUID         `json:"id"`
+	UserID     uuid.UUID         `json:"user_id" validate:"required"`
+	CardID     uuid.UUID         `json:"card_id" validate:"required"`
+	Period     PayoutRulePeriod  `json:"period" validate:"required,oneof=weekly monthly"`
+	Weekday    *int              `json:"weekday,omitempty" validate:"required_if=Period weekly,omitempty,min=0,max=6"`
+	DayOfMonth *int              `json:"day_of_month,omitempty" validate:"required_if=Period monthly,omitempty,min=1,max=28"`
+	MinAmount  string            `json:"min_amount" validate:"required,money"`
+	Currency   yoomodel.Currency `json:"currency" validate:"required,iso4217"`
+	Enabled    bool              `json:"enabled"`
+	JobID      *uuid.UUID        `json:"-"`
+	NextRunAt  *time.Time        `json:"next_run_at,omitempty"`
+	LastRunAt  *time.Time        `json:"last_run_at,omitempty"`
+	CreatedAt  time.Time         `json:"created_at"`
+	UpdatedAt  time.Time         `json:"updated_at"`
+}
+
+// NextRun returns the first run time of the rule strictly after the given time.
+func (r *PayoutRule) NextRun(after time.Time) time.Time {
+	after = after.UTC()
+
+	if r.Period == WeeklyPayoutRule {
+		next := time.Date(after.Year(), after.Month(), after.Day(), PayoutRuleHour, 0, 0, 0, time.UTC)
+		next = next.AddDate(0, 0, (*r.Weekday-int(next.Weekday())+7)%7)
+
+		if !next.After(after) {
+			next = next.AddDate(0, 0, 7)
+		}
+
+		return next
+	}
+
+	next := time.Date(after.Year(), after.Month(), *r.DayOfMonth, PayoutRuleHour, 0, 0, 0, time.UTC)
+	if !next.After(after) {
+		next = next.AddDate(0, 1, 0)
+	}
+
+	return next
+}
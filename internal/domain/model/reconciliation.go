@@ -0,0 +1,41 @@
+package model
+
+import (
+	yoomodel "github.com/eclipsemode/go-yookassa-sdk/yookassa/model"
+	"github.com/google/uuid"
+	"time"
+)
+
+type DiscrepancyKind string
+
+const (
+	MissingLocally  DiscrepancyKind = "missing_locally"
+	MissingUpstream DiscrepancyKind = "missing_upstream"
+	StatusMismatch  DiscrepancyKind = "status_mismatch"
+	AmountMismatch  DiscrepancyKind = "amount_mismatch"
+)
+
+// Discrepancy is a difference between a logs row and the same transaction in YooKassa.
+// Local fields are empty for MissingLocally, upstream fields are empty for MissingUpstream.
+type Discrepancy struct {
+	ID               uuid.UUID                  `json:"id"`
+	TransactionID    string                     `json:"transaction_id"`
+	TransactionType  yoomodel.TransactionType   `json:"transaction_type"`
+	Kind             DiscrepancyKind            `json:"kind"`
+	LocalStatus      yoomodel.TransactionStatus `json:"local_status,omitempty"`
+	UpstreamStatus   yoomodel.TransactionStatus `json:"upstream_status,omitempty"`
+	LocalValue       string                     `json:"local_value,omitempty"`
+	UpstreamValue    string                     `json:"upstream_value,omitempty"`
+	LocalCurrency    yoomodel.Currency          `json:"local_currency,omitempty"`
+	UpstreamCurrency yoomodel.Currency          `json:"upstream_currency,omitempty"`
+}
+
+// ReconciliationReport is the result of comparing one UTC day of payments and payouts with YooKassa.
+type ReconciliationReport struct {
+	ID              uuid.UUID     `json:"id"`
+	Date            time.Time     `json:"date"`
+	PaymentsChecked int           `json:"payments_checked"`
+	PayoutsChecked  int           `json:"payouts_checked"`
+	Discrepancies   []Discrepancy `json:"discrepancies"`
+	CreatedAt       time.Time     `json:"created_at"`
+}
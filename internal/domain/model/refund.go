@@ -0,0 +1,27 @@
+package model
+
+import (
+	yoomodel "github.com/eclipsemode/go-yookassa-sdk/yookassa/model"
+	"github.com/google/uuid"
+	"time"
+)
+
+type Refund struct {
+	ID             uuid.UUID                  `json:"id"`
+	RefundID       string                     `json:"refund_id" validate:"required"`
+	PaymentID      string                     `json:"payment_id" validate:"required"`
+	Status         yoomodel.TransactionStatus `json:"status" validate:"required"`
+	Value          string                     `json:"value" validate:"required,money"`
+	Currency       yoomodel.Currency          `json:"currency" validate:"required,iso4217"`
+	Description    string                     `json:"description,omitempty"`
+	IdempotenceKey string                     `json:"idempotence_key"`
+	CreatedAt      time.Time                  `json:"created_at"`
+	UpdatedAt      time.Time                  `json:"updated_at"`
+}
+
+// RefundRequest is the body of a new refund of the payment.
+type RefundRequest struct {
+	PaymentID   string `json:"-"`
+	Amount      Amount `json:"amount" validate:"required"`
+	Description string `json:"description,omitempty" validate:"omitempty,max=250"`
+}
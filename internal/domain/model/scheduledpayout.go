@@ -0,0 +1,36 @@
+package model
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+type ScheduledPayoutStatus string
+
+const (
+	ScheduledPayoutPending    ScheduledPayoutStatus = "pending"
+	ScheduledPayoutProcessing ScheduledPayoutStatus = "processing"
+	ScheduledPayoutDone       ScheduledPayoutStatus = "done"
+	ScheduledPayoutFailed     ScheduledPayoutStatus = "failed"
+	ScheduledPayoutCanceled   ScheduledPayoutStatus = "canceled"
+
+	// ScheduledPayoutUnconfirmed is a payout whose execution was never answered by the provider within the
+	// idempotence key lifetime. It may or may not have been paid and has to be checked manually.
+	ScheduledPayoutUnconfirmed ScheduledPayoutStatus = "unconfirmed"
+)
+
+// ScheduledPayout is a payout request executed at ExecuteAt. The idempotence key is generated
+// when the payout is scheduled, so executing it again after a crash cannot pay twice.
+type ScheduledPayout struct {
+	ID             uuid.UUID             `json:"id"`
+	JobID          *uuid.UUID            `json:"-"`
+	Payout         PayoutRequest         `json:"payout"`
+	IdempotenceKey string                `json:"-"`
+	ExecuteAt      time.Time             `json:"execute_at"`
+	Status         ScheduledPayoutStatus `json:"status"`
+	PayoutID       string                `json:"payout_id,omitempty"`
+	LastError      string                `json:"last_error,omitempty"`
+	StartedAt      *time.Time            `json:"started_at,omitempty"`
+	CreatedAt      time.Time             `json:"created_at"`
+	UpdatedAt      time.Time             `json:"updated_at"`
+}
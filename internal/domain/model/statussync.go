@@ -0,0 +1,26 @@
+package model
+
+import (
+	yoomodel "github.com/eclipsemode/go-yookassa-sdk/yookassa/model"
+	"time"
+)
+
+type StatusSyncState string
+
+const (
+	StatusSyncPending StatusSyncState = "pending"
+	StatusSyncFailed  StatusSyncState = "failed"
+)
+
+// StatusSyncItem is a transaction whose status is polled from the provider until it is final. NextAttemptAt
+// is stored in Postgres, so polling resumes after a restart. An item that was given up on is kept as failed.
+type StatusSyncItem struct {
+	TransactionID   string                   `json:"transaction_id"`
+	TransactionType yoomodel.TransactionType `json:"transaction_type"`
+	State           StatusSyncState          `json:"state"`
+	Attempts        int                      `json:"attempts"`
+	NextAttemptAt   time.Time                `json:"next_attempt_at"`
+	LastError       string                   `json:"last_error,omitempty"`
+	CreatedAt       time.Time                `json:"created_at"`
+	UpdatedAt       time.Time                `json:"updated_at"`
+}
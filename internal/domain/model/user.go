@@ -1,21 +1,48 @@
 package model
 
 import (
-	"fmt"
+	yoomodel "github.com/eclipsemode/go-yookassa-sdk/yookassa/model"
 	"github.com/google/uuid"
 	"time"
 )
 
+// User is a host receiving money for bookings. Users live in the main service, here a user is only
+// the owner of ledger accounts, one per currency.
 type User struct {
-	ID        uuid.UUID `json:"id"`
-	Balance   float64   `json:"balance"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID       uuid.UUID `json:"id"`
+	Balances []Balance `json:"balances"`
 }
 
+// Balance is what the user is owed in one currency. It may go negative when a payment is refunded
+// after it was already paid out.
+type Balance struct {
+	Currency  yoomodel.Currency `json:"currency"`
+	Value     string            `json:"value"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+// BalanceChange is one ledger entry of a user account: positive values credit the user, negative debit.
 type BalanceChange struct {
+	ID          uuid.UUID         `json:"id"`
+	PostingID   uuid.UUID         `json:"posting_id"`
+	Kind        PostingKind       `json:"kind"`
+	ReferenceID string            `json:"reference_id"`
+	Value       string            `json:"value"`
+	Currency    yoomodel.Currency `json:"currency"`
+	CreatedAt   time.Time         `json:"created_at"`
+}
+
+// LedgerFilter narrows down the user ledger query. An empty currency returns entries of every account.
+// The json names are the query parameters, validation errors refer to them.
+type LedgerFilter struct {
+	UserID   uuid.UUID         `json:"-"`
+	Currency yoomodel.Currency `json:"currency" validate:"omitempty,iso4217"`
+	Order    SortOrder         `json:"order" validate:"required,oneof=asc desc"`
+	Limit    int               `json:"limit" validate:"min=1,max=500"`
+	Cursor   *Cursor           `json:"cursor"`
 }
 
-func (u *User) ToString() string {
-	return fmt.Sprintf("ID: %s, Balance: %f", u.ID, u.Balance)
+type LedgerPage struct {
+	Items      []BalanceChange `json:"items"`
+	NextCursor string          `json:"next_cursor,omitempty"`
 }
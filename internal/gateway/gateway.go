@@ -0,0 +1,61 @@
+// Package gateway hides payment providers behind interfaces of our own domain types, so handlers and
+// services do not depend on a provider SDK. The provider is chosen in config.
+package gateway
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/imperatorofdwelling/payment-svc/internal/config"
+	"github.com/imperatorofdwelling/payment-svc/internal/domain/model"
+	"go.uber.org/zap"
+	"time"
+)
+
+// Providers accepted as gateway.provider in config.
+const (
+	YooKassaProvider = "yookassa"
+)
+
+var (
+	ErrUnknownProvider = errors.New("unknown payment provider")
+	ErrNotFound        = errors.New("object is not found at the payment provider")
+	ErrRejected        = errors.New("request is rejected by the payment provider")
+	ErrInvalidResponse = errors.New("invalid response from the payment provider")
+	// ErrUnavailable means the provider could not be reached, kept failing or is not called for a while
+	// after an outage. The request may or may not have been processed, repeat it with the same key.
+	ErrUnavailable = errors.New("payment provider is unavailable")
+)
+
+// PaymentGateway accepts payments and refunds them. Returned objects hold the actual state at the provider
+// and are not stored yet: their own ids, idempotence keys and timestamps are empty.
+type PaymentGateway interface {
+	CreatePayment(ctx context.Context, req *model.PaymentRequest, idempotenceKey string) (*model.Payment, error)
+	GetPayment(ctx context.Context, id string) (*model.Payment, error)
+	// CapturePayment confirms a held payment, a nil amount captures all of it.
+	CapturePayment(ctx context.Context, id string, amount *model.Money, idempotenceKey string) (*model.Payment, error)
+	CancelPayment(ctx context.Context, id string, idempotenceKey string) (*model.Payment, error)
+	// ListPayments returns every payment created in [from, to).
+	ListPayments(ctx context.Context, from, to time.Time) ([]model.Payment, error)
+	CreateRefund(ctx context.Context, req *model.RefundRequest, idempotenceKey string) (*model.Refund, error)
+	GetRefund(ctx context.Context, id string) (*model.Refund, error)
+}
+
+// PayoutGateway pays money out to hosts.
+type PayoutGateway interface {
+	CreatePayout(ctx context.Context, req *model.PayoutRequest, idempotenceKey string) (*model.Payout, error)
+	GetPayout(ctx context.Context, id string) (*model.Payout, error)
+	// ListPayouts returns every payout created in [from, to).
+	ListPayouts(ctx context.Context, from, to time.Time) ([]model.Payout, error)
+}
+
+// New returns the gateways of the configured provider.
+func New(cfg *config.Config, log *zap.SugaredLogger) (PaymentGateway, PayoutGateway, error) {
+	switch cfg.Gateway.Provider {
+	case YooKassaProvider:
+		yooKassa := NewYooKassa(cfg.PayApi, log)
+		return yooKassa, yooKassa, nil
+	default:
+		return nil, nil, fmt.Errorf("%w: %q", ErrUnknownProvider, cfg.Gateway.Provider)
+	}
+}
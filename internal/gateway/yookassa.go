@@ -0,0 +1,401 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	yoomodel "github.com/eclipsemode/go-yookassa-sdk/yookassa/model"
+	"github.com/imperatorofdwelling/payment-svc/internal/config"
+	"github.com/imperatorofdwelling/payment-svc/internal/domain/model"
+	"github.com/imperatorofdwelling/payment-svc/internal/lib/yooapi"
+	"github.com/imperatorofdwelling/payment-svc/pkg/json"
+	"go.uber.org/zap"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// YooKassa implements both gateways with the YooKassa API.
+type YooKassa struct {
+	api *yooapi.Client
+}
+
+func NewYooKassa(cfg config.PayApi, log *zap.SugaredLogger) *YooKassa {
+	policy := yooapi.Policy{
+		Timeout:          cfg.Timeout,
+		MaxRetries:       cfg.MaxRetries,
+		RetryBackoff:     cfg.RetryBackoff,
+		BreakerThreshold: cfg.BreakerThreshold,
+		BreakerCooldown:  cfg.BreakerCooldown,
+	}
+
+	return &YooKassa{
+		api: yooapi.NewClient(cfg.ApiAddr, cfg.ShopID, cfg.SecretKey, cfg.PayoutAgentID, cfg.PayoutSecretKey, policy, log),
+	}
+}
+
+// apiError is the body of YooKassa error responses.
+type apiError struct {
+	Code        string `json:"code"`
+	Description string `json:"description"`
+	Parameter   string `json:"parameter,omitempty"`
+}
+
+func (g *YooKassa) CreatePayment(ctx context.Context, req *model.PaymentRequest, idempotenceKey string) (*model.Payment, error) {
+	const op = "gateway.yookassa.CreatePayment"
+
+	amount, err := req.Amount.Money()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	body := &yooapi.PaymentRequest{
+		Amount:            amount.ToAmount(),
+		Description:       req.Description,
+		Capture:           req.Capture,
+		PaymentMethodID:   req.PaymentMethodID,
+		PaymentToken:      req.PaymentToken,
+		SavePaymentMethod: req.SavePaymentMethod,
+		ClientIP:          req.ClientIP,
+		Metadata:          req.Metadata,
+	}
+
+	if req.Confirmation != nil {
+		body.Confirmation = &yoomodel.Confirmation{
+			Type:      req.Confirmation.Type,
+			ReturnURL: req.Confirmation.ReturnURL,
+			Locale:    req.Confirmation.Locale,
+			Enforce:   req.Confirmation.Enforce,
+		}
+	}
+
+	if req.PaymentMethodData != nil {
+		body.PaymentMethodData = &yooapi.PaymentMethodData{
+			Type:  req.PaymentMethodData.Type,
+			Phone: req.PaymentMethodData.Phone,
+		}
+	}
+
+	payment, err := readPayment(g.api.CreatePayment(ctx, body, idempotenceKey))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return payment, nil
+}
+
+func (g *YooKassa) GetPayment(ctx context.Context, id string) (*model.Payment, error) {
+	const op = "gateway.yookassa.GetPayment"
+
+	payment, err := readPayment(g.api.GetPaymentInfo(ctx, id))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return payment, nil
+}
+
+func (g *YooKassa) CapturePayment(ctx context.Context, id string, amount *model.Money, idempotenceKey string) (*model.Payment, error) {
+	const op = "gateway.yookassa.CapturePayment"
+
+	var captured *yoomodel.Amount
+	if amount != nil {
+		a := amount.ToAmount()
+		captured = &a
+	}
+
+	payment, err := readPayment(g.api.CapturePayment(ctx, id, captured, idempotenceKey))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return payment, nil
+}
+
+func (g *YooKassa) CancelPayment(ctx context.Context, id string, idempotenceKey string) (*model.Payment, error) {
+	const op = "gateway.yookassa.CancelPayment"
+
+	payment, err := readPayment(g.api.CancelPayment(ctx, id, idempotenceKey))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return payment, nil
+}
+
+func (g *YooKassa) ListPayments(ctx context.Context, from, to time.Time) ([]model.Payment, error) {
+	const op = "gateway.yookassa.ListPayments"
+
+	payments := make([]model.Payment, 0)
+
+	err := listAll(ctx, g.api.ListPayments, from, to, func(p yoomodel.Payment) error {
+		payment, err := paymentFromYoo(&p)
+		if err != nil {
+			return err
+		}
+
+		payments = append(payments, *payment)
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return payments, nil
+}
+
+func (g *YooKassa) CreateRefund(ctx context.Context, req *model.RefundRequest, idempotenceKey string) (*model.Refund, error) {
+	const op = "gateway.yookassa.CreateRefund"
+
+	amount, err := req.Amount.Money()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	refund, err := readRefund(g.api.CreateRefund(ctx, &yooapi.Refund{
+		PaymentID:   req.PaymentID,
+		Amount:      amount.ToAmount(),
+		Description: req.Description,
+	}, idempotenceKey))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return refund, nil
+}
+
+func (g *YooKassa) GetRefund(ctx context.Context, id string) (*model.Refund, error) {
+	const op = "gateway.yookassa.GetRefund"
+
+	refund, err := readRefund(g.api.GetRefundInfo(ctx, id))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return refund, nil
+}
+
+func (g *YooKassa) CreatePayout(ctx context.Context, req *model.PayoutRequest, idempotenceKey string) (*model.Payout, error) {
+	const op = "gateway.yookassa.CreatePayout"
+
+	amount, err := req.Amount.Money()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	body := &yooapi.PayoutRequest{
+		Amount:      amount.ToAmount(),
+		PayoutToken: req.PayoutToken,
+		Description: req.Description,
+		Metadata:    req.Metadata,
+	}
+
+	if dst := req.PayoutDestination; dst != nil {
+		body.PayoutDestinationData = &yooapi.PayoutDestinationData{
+			Type:          dst.Type,
+			BankID:        dst.BankID,
+			Phone:         dst.Phone,
+			AccountNumber: dst.AccountNumber,
+		}
+
+		if dst.Card != nil {
+			body.PayoutDestinationData.Card = &yooapi.PayoutCard{Number: dst.Card.Number}
+		}
+	}
+
+	payout, err := readPayout(g.api.CreatePayout(ctx, body, idempotenceKey))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return payout, nil
+}
+
+func (g *YooKassa) GetPayout(ctx context.Context, id string) (*model.Payout, error) {
+	const op = "gateway.yookassa.GetPayout"
+
+	payout, err := readPayout(g.api.GetPayoutInfo(ctx, id))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return payout, nil
+}
+
+func (g *YooKassa) ListPayouts(ctx context.Context, from, to time.Time) ([]model.Payout, error) {
+	const op = "gateway.yookassa.ListPayouts"
+
+	payouts := make([]model.Payout, 0)
+
+	err := listAll(ctx, g.api.ListPayouts, from, to, func(p yoomodel.Payout) error {
+		payout, err := payoutFromYoo(&p)
+		if err != nil {
+			return err
+		}
+
+		payouts = append(payouts, *payout)
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return payouts, nil
+}
+
+func readPayment(res *http.Response, err error) (*model.Payment, error) {
+	var payment yoomodel.Payment
+
+	if err := readResponse(res, err, &payment); err != nil {
+		return nil, err
+	}
+
+	return paymentFromYoo(&payment)
+}
+
+func readRefund(res *http.Response, err error) (*model.Refund, error) {
+	var refund yooapi.Refund
+
+	if err := readResponse(res, err, &refund); err != nil {
+		return nil, err
+	}
+
+	return refundFromYoo(&refund)
+}
+
+func readPayout(res *http.Response, err error) (*model.Payout, error) {
+	var payout yoomodel.Payout
+
+	if err := readResponse(res, err, &payout); err != nil {
+		return nil, err
+	}
+
+	return payoutFromYoo(&payout)
+}
+
+// readResponse decodes a successful response into dst. Error responses are turned into ErrNotFound or
+// ErrRejected with the description YooKassa gave.
+func readResponse(res *http.Response, err error, dst any) error {
+	if errors.Is(err, yooapi.ErrUnavailable) {
+		return fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode == http.StatusNotFound {
+		res.Body.Close()
+		return ErrNotFound
+	}
+
+	if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusMultipleChoices {
+		var apiErr apiError
+
+		_ = json.Read(res.Body, &apiErr)
+
+		return fmt.Errorf("%w: %s: %s", ErrRejected, res.Status, apiErr.Description)
+	}
+
+	return json.Read(res.Body, dst)
+}
+
+// listAll walks every page of a YooKassa list for objects created in [from, to).
+func listAll[T any](
+	ctx context.Context,
+	list func(ctx context.Context, query url.Values) (*http.Response, error),
+	from, to time.Time,
+	fn func(T) error,
+) error {
+	var cursor string
+
+	for {
+		var page yooapi.List[T]
+
+		res, err := list(ctx, yooapi.ListQuery(from, to, cursor))
+		if err := readResponse(res, err, &page); err != nil {
+			return err
+		}
+
+		for _, item := range page.Items {
+			if err := fn(item); err != nil {
+				return err
+			}
+		}
+
+		if page.NextCursor == "" {
+			return nil
+		}
+
+		cursor = page.NextCursor
+	}
+}
+
+// paymentFromYoo maps the YooKassa payment object to our payment.
+func paymentFromYoo(p *yoomodel.Payment) (*model.Payment, error) {
+	if p.ID == "" || p.Status == "" {
+		return nil, fmt.Errorf("%w: payment without id or status", ErrInvalidResponse)
+	}
+
+	payment := &model.Payment{
+		PaymentID:         p.ID,
+		Status:            p.Status,
+		Description:       p.Description,
+		Metadata:          p.Metadata,
+		ConfirmationType:  p.Confirmation.Type,
+		ConfirmationURL:   p.Confirmation.ConfirmationURL,
+		ConfirmationToken: p.Confirmation.ConfirmationToken,
+		PaymentMethodType: p.PaymentMethodData.Type,
+		Paid:              p.Paid,
+		Refundable:        p.Refundable,
+		Capture:           p.Capture,
+		Test:              p.Test,
+		CaptureDeadline:   p.ExpiresAt,
+		CapturedAt:        p.CapturedAt,
+	}
+
+	if p.Amount != nil {
+		amount, err := model.MoneyFromAmount(*p.Amount)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidResponse, err)
+		}
+		payment.Amount = amount
+	}
+
+	return payment, nil
+}
+
+// payoutFromYoo maps the YooKassa payout object to our payout. The card and the user are not known to
+// YooKassa, they are found by the payout token when the payout is stored.
+func payoutFromYoo(p *yoomodel.Payout) (*model.Payout, error) {
+	if p.ID == "" || p.Status == "" {
+		return nil, fmt.Errorf("%w: payout without id or status", ErrInvalidResponse)
+	}
+
+	return &model.Payout{
+		PayoutID:    p.ID,
+		Status:      p.Status,
+		Value:       p.Amount.Value,
+		Currency:    p.Amount.Currency,
+		Description: p.Description,
+		Metadata:    p.Metadata,
+		PayoutToken: p.PayoutToken,
+		Test:        p.Test,
+	}, nil
+}
+
+func refundFromYoo(r *yooapi.Refund) (*model.Refund, error) {
+	if r.ID == "" || r.Status == "" {
+		return nil, fmt.Errorf("%w: refund without id or status", ErrInvalidResponse)
+	}
+
+	return &model.Refund{
+		RefundID:    r.ID,
+		PaymentID:   r.PaymentID,
+		Status:      r.Status,
+		Value:       r.Amount.Value,
+		Currency:    r.Amount.Currency,
+		Description: r.Description,
+	}, nil
+}
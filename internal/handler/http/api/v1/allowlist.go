@@ -0,0 +1,120 @@
+package v1
+
+import (
+	"fmt"
+	"github.com/imperatorofdwelling/payment-svc/pkg/json"
+	"go.uber.org/zap"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// newIPAllowlist builds a middleware rejecting requests whose source address is outside the given
+// addresses and CIDR ranges. The source address is the peer of the connection, so the route must not be
+// behind middleware.RealIP. Only requests from trustedProxies are attributed to the address they forward.
+func newIPAllowlist(allowed, trustedProxies []string, log *zap.SugaredLogger) (func(http.Handler) http.Handler, error) {
+	nets, err := parseIPNets(allowed)
+	if err != nil {
+		return nil, fmt.Errorf("invalid allowed ips: %w", err)
+	}
+
+	proxies, err := parseIPNets(trustedProxies)
+	if err != nil {
+		return nil, fmt.Errorf("invalid trusted proxies: %w", err)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ip := sourceIP(r, proxies)
+
+			if ip != nil && containsIP(nets, ip) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			log.Warnf("rejected request to %s from %s (peer %s)", r.URL.Path, ip, r.RemoteAddr)
+			json.WriteError(w, http.StatusForbidden, ErrSourceNotAllowed.Error(), json.AuthorizationError)
+		})
+	}, nil
+}
+
+// sourceIP returns the peer address of the request. When the peer is a trusted proxy, it returns the last
+// X-Forwarded-For address that is not a trusted proxy, as earlier ones are set by the client, or X-Real-IP.
+func sourceIP(r *http.Request, proxies []*net.IPNet) net.IP {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	peer := net.ParseIP(host)
+	if peer == nil || !containsIP(proxies, peer) {
+		return peer
+	}
+
+	if forwarded := strings.Join(r.Header.Values("X-Forwarded-For"), ","); forwarded != "" {
+		hops := strings.Split(forwarded, ",")
+
+		for i := len(hops) - 1; i >= 0; i-- {
+			ip := net.ParseIP(strings.TrimSpace(hops[i]))
+			if ip == nil {
+				return nil
+			}
+
+			if !containsIP(proxies, ip) {
+				return ip
+			}
+		}
+	}
+
+	if ip := net.ParseIP(strings.TrimSpace(r.Header.Get("X-Real-IP"))); ip != nil {
+		return ip
+	}
+
+	return peer
+}
+
+// parseIPNets parses plain addresses and CIDR ranges, a plain address becomes a single-address range.
+func parseIPNets(entries []string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(entries))
+
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid ip %q", entry)
+			}
+
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, ipNet, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ip range %q: %w", entry, err)
+		}
+
+		nets = append(nets, ipNet)
+	}
+
+	return nets, nil
+}
+
+func containsIP(nets []*net.IPNet, ip net.IP) bool {
+	for _, ipNet := range nets {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -1,8 +1,47 @@
 package v1
 
-import "errors"
+import (
+	"errors"
+	"github.com/imperatorofdwelling/payment-svc/internal/gateway"
+	v10 "github.com/imperatorofdwelling/payment-svc/internal/lib/validator"
+	"github.com/imperatorofdwelling/payment-svc/pkg/json"
+	"go.uber.org/zap"
+	"net/http"
+)
 
 var (
 	ErrGettingIdempotenceKey = errors.New("error getting idempotence key")
 	ErrUnmarshallingBody     = errors.New(`error unmarshalling body`)
+	ErrSourceNotAllowed      = errors.New("request source is not allowed")
+	ErrIdempotenceKeyReused  = errors.New("idempotence key was already used with another request")
+	ErrRequestInProgress     = errors.New("request with this idempotence key is still in progress")
 )
+
+// writeValidationError responds with the failed fields described in the language the client accepts.
+func writeValidationError(w http.ResponseWriter, r *http.Request, err error) {
+	fields := v10.FieldErrors(err, r.Header.Get("Accept-Language"))
+	if len(fields) == 0 {
+		json.WriteError(w, http.StatusBadRequest, err.Error(), json.ValidationError)
+		return
+	}
+
+	json.WriteFieldErrors(w, http.StatusBadRequest, fields)
+}
+
+// writeGatewayError responds to a failed call to the payment provider. Requests the provider refused are
+// the client's fault. An unavailable provider gets its own error type, the client should repeat the request
+// later with the same idempotence key. Anything else means the provider answered nonsense.
+func writeGatewayError(w http.ResponseWriter, log *zap.SugaredLogger, op string, err error) {
+	log.Errorf("%s: %v", op, err)
+
+	switch {
+	case errors.Is(err, gateway.ErrNotFound):
+		json.WriteError(w, http.StatusNotFound, err.Error(), json.NotFoundError)
+	case errors.Is(err, gateway.ErrRejected):
+		json.WriteError(w, http.StatusBadRequest, err.Error(), json.ExternalApiError)
+	case errors.Is(err, gateway.ErrUnavailable):
+		json.WriteError(w, http.StatusServiceUnavailable, err.Error(), json.ProviderUnavailableError)
+	default:
+		json.WriteError(w, http.StatusBadGateway, err.Error(), json.ExternalApiError)
+	}
+}
@@ -0,0 +1,144 @@
+package v1
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/imperatorofdwelling/payment-svc/internal/config"
+	"github.com/imperatorofdwelling/payment-svc/internal/domain/model"
+	"github.com/imperatorofdwelling/payment-svc/internal/storage/redis"
+	"github.com/imperatorofdwelling/payment-svc/pkg/json"
+	"go.uber.org/zap"
+	"io"
+	"net/http"
+)
+
+const replayedHeader = "Idempotent-Replayed"
+
+// NewIdempotency builds a middleware that handles a POST request with an Idempotence-Key only once.
+// A retry with the same key and body gets the stored response, a retry with another body is rejected
+// with 409 and a retry while the first request is still being handled gets 425. Only successful responses
+// are stored: YooKassa failures are answered with 4xx too and the request must stay retryable after them.
+// Requests without the key are passed through.
+func NewIdempotency(repo redis.IIdempotencyRepo, cfg config.Idempotency, log *zap.SugaredLogger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			const op = "handler.v1.idempotency"
+
+			idempotenceKey := r.Header.Get("Idempotence-Key")
+			if r.Method != http.MethodPost || idempotenceKey == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				json.WriteError(w, http.StatusBadRequest, err.Error(), json.DecodeBodyError)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			// Keys are scoped by the endpoint, the same key may be used for a payment and its capture.
+			key := r.Method + ":" + r.URL.Path + ":" + idempotenceKey
+			fingerprint := requestFingerprint(r, body)
+
+			request, started, err := repo.Start(key, fingerprint, cfg.InProgressTTL)
+			if err != nil {
+				log.Errorf("%s: %v", op, err)
+				json.WriteError(w, http.StatusInternalServerError, err.Error(), json.InternalApiError)
+				return
+			}
+
+			if !started {
+				switch {
+				case request.Fingerprint != fingerprint:
+					json.WriteError(w, http.StatusConflict, ErrIdempotenceKeyReused.Error(), json.ConflictError)
+				case !request.IsCompleted():
+					json.WriteError(w, http.StatusTooEarly, ErrRequestInProgress.Error(), json.InProgressError)
+				default:
+					replayResponse(w, request.Response, log)
+				}
+				return
+			}
+
+			completed := false
+
+			// The key is freed when the request fails or panics, the client retries it from scratch.
+			defer func() {
+				if completed {
+					return
+				}
+
+				if err := repo.Release(key, request); err != nil {
+					log.Errorf("%s: %v", op, err)
+				}
+			}()
+
+			rec := &responseRecorder{ResponseWriter: w}
+
+			next.ServeHTTP(rec, r)
+
+			if rec.statusCode == 0 || rec.statusCode >= http.StatusBadRequest {
+				return
+			}
+
+			request.Response = &model.IdempotentResponse{
+				StatusCode:  rec.statusCode,
+				ContentType: rec.Header().Get("Content-Type"),
+				Body:        rec.body.Bytes(),
+			}
+
+			if err := repo.Complete(key, request, cfg.TTL); err != nil {
+				log.Errorf("%s: %v", op, err)
+				return
+			}
+
+			completed = true
+		})
+	}
+}
+
+func requestFingerprint(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(r.Method + " " + r.URL.Path + "\n"))
+	hash.Write(body)
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func replayResponse(w http.ResponseWriter, res *model.IdempotentResponse, log *zap.SugaredLogger) {
+	if res.ContentType != "" {
+		w.Header().Set("Content-Type", res.ContentType)
+	}
+	w.Header().Set(replayedHeader, "true")
+	w.WriteHeader(res.StatusCode)
+
+	if _, err := w.Write(res.Body); err != nil {
+		log.Errorf("handler.v1.idempotency.replayResponse: %v", err)
+	}
+}
+
+// responseRecorder passes the response through and keeps a copy of it.
+type responseRecorder struct {
+	http.ResponseWriter
+	statusCode int
+	body       bytes.Buffer
+}
+
+func (rec *responseRecorder) WriteHeader(statusCode int) {
+	if rec.statusCode == 0 {
+		rec.statusCode = statusCode
+	}
+
+	rec.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (rec *responseRecorder) Write(b []byte) (int, error) {
+	if rec.statusCode == 0 {
+		rec.statusCode = http.StatusOK
+	}
+
+	rec.body.Write(b)
+
+	return rec.ResponseWriter.Write(b)
+}
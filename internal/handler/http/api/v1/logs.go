@@ -1,12 +1,25 @@
 package v1
 
 import (
+	"errors"
+	"fmt"
 	yoomodel "github.com/eclipsemode/go-yookassa-sdk/yookassa/model"
 	"github.com/go-chi/chi/v5"
+	"github.com/imperatorofdwelling/payment-svc/internal/domain/model"
+	"github.com/imperatorofdwelling/payment-svc/internal/lib/export"
+	v10 "github.com/imperatorofdwelling/payment-svc/internal/lib/validator"
 	"github.com/imperatorofdwelling/payment-svc/internal/service"
+	"github.com/imperatorofdwelling/payment-svc/internal/storage/postgres"
 	"github.com/imperatorofdwelling/payment-svc/pkg/json"
 	"go.uber.org/zap"
 	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultLogsLimit = 50
 )
 
 type logsHandler struct {
@@ -18,29 +31,168 @@ func NewLogsHandler(r chi.Router, svc service.ILogsSvc, log *zap.SugaredLogger)
 	handler := &logsHandler{svc: svc, log: log}
 
 	r.Route("/logs", func(r chi.Router) {
-		r.Post("/status", handler.changeStatus)
+		r.Get("/", handler.getLogs)
+		r.Get("/{transactionId}/history", handler.getStatusHistory)
 	})
 }
 
-// TODO implement changeStatus handler when server will be published
-func (h *logsHandler) changeStatus(w http.ResponseWriter, r *http.Request) {
-	const op = "handler.v1.lost.changeStatus"
+// NewLogsExportHandler mounts the logs export. An export streams for as long as the client keeps reading,
+// so r must not use middleware.Timeout.
+func NewLogsExportHandler(r chi.Router, svc service.ILogsSvc, log *zap.SugaredLogger) {
+	handler := &logsHandler{svc: svc, log: log}
+
+	r.Get("/logs/export", handler.exportLogs)
+}
+
+func (h *logsHandler) getStatusHistory(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.v1.logs.getStatusHistory"
+
+	transactionID := chi.URLParam(r, "transactionId")
+
+	history, err := h.svc.GetStatusHistory(r.Context(), transactionID)
+	if errors.Is(err, postgres.ErrLogNotFound) {
+		json.WriteError(w, http.StatusNotFound, err.Error(), json.NotFoundError)
+		return
+	}
+	if err != nil {
+		h.log.Errorf("%s: %v", op, err)
+		json.WriteError(w, http.StatusInternalServerError, err.Error(), json.InternalApiError)
+		return
+	}
+
+	json.Write(w, http.StatusOK, history)
+}
 
-	var notification yoomodel.Notification
+// getLogs lists logs filtered by query parameters: type, status, currency, amount_from, amount_to,
+// created_from and created_to (RFC 3339), order (asc or desc, newest first by default), limit and cursor.
+func (h *logsHandler) getLogs(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.v1.logs.getLogs"
 
-	err := json.Read(r.Body, &notification)
+	filter, err := parseLogFilter(r.URL.Query())
 	if err != nil {
-		h.log.Error(op, zap.Error(err))
-		json.WriteError(w, http.StatusBadRequest, err.Error(), json.DecodeBodyError)
+		json.WriteError(w, http.StatusBadRequest, err.Error(), json.ParseError)
+		return
+	}
+
+	if err := v10.Validate.Struct(filter); err != nil {
+		writeValidationError(w, r, err)
 		return
 	}
 
-	err = h.svc.UpdateLogTransactionStatus(r.Context(), notification.Object.ID, notification.Object.Status)
+	page, err := h.svc.GetLogs(r.Context(), filter)
 	if err != nil {
-		h.log.Error(op, zap.Error(err))
-		json.WriteError(w, http.StatusInternalServerError, err.Error(), json.DecodeBodyError)
+		h.log.Errorf("%s: %v", op, err)
+		json.WriteError(w, http.StatusInternalServerError, err.Error(), json.InternalApiError)
+		return
+	}
+
+	json.Write(w, http.StatusOK, page)
+}
+
+// exportLogs streams every log matching the getLogs filters as CSV or JSON lines, oldest first unless
+// order is given. Once streaming has started errors can only be logged, the status code is already sent.
+func (h *logsHandler) exportLogs(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.v1.logs.exportLogs"
+
+	q := r.URL.Query()
+
+	format, err := export.ParseFormat(q.Get("format"))
+	if err != nil {
+		json.WriteError(w, http.StatusBadRequest, err.Error(), json.ValidationError)
+		return
+	}
+
+	filter, err := parseLogFilter(q)
+	if err != nil {
+		json.WriteError(w, http.StatusBadRequest, err.Error(), json.ParseError)
+		return
+	}
+
+	if q.Get("order") == "" {
+		filter.Order = model.SortAsc
+	}
+
+	if err := v10.Validate.Struct(filter); err != nil {
+		writeValidationError(w, r, err)
 		return
 	}
 
-	json.Write(w, http.StatusNoContent, nil)
+	writer, err := export.NewLogsWriter(w, format)
+	if err != nil {
+		json.WriteError(w, http.StatusBadRequest, err.Error(), json.ValidationError)
+		return
+	}
+
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="logs.%s"`, format))
+	w.WriteHeader(http.StatusOK)
+
+	err = h.svc.ExportLogs(r.Context(), filter, writer.Write)
+	if err != nil {
+		h.log.Errorf("%s: %v", op, err)
+		return
+	}
+
+	if err := writer.Flush(); err != nil {
+		h.log.Errorf("%s: %v", op, err)
+	}
+}
+
+func parseLogFilter(q url.Values) (model.LogFilter, error) {
+	filter := model.LogFilter{
+		TransactionType: yoomodel.TransactionType(q.Get("type")),
+		Status:          yoomodel.TransactionStatus(q.Get("status")),
+		Currency:        yoomodel.Currency(q.Get("currency")),
+		AmountFrom:      q.Get("amount_from"),
+		AmountTo:        q.Get("amount_to"),
+		Order:           model.SortDesc,
+		Limit:           defaultLogsLimit,
+	}
+
+	if order := q.Get("order"); order != "" {
+		filter.Order = model.SortOrder(order)
+	}
+
+	if limit := q.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil {
+			return filter, fmt.Errorf("invalid limit: %w", err)
+		}
+		filter.Limit = n
+	}
+
+	var err error
+
+	filter.CreatedFrom, err = parseTimeParam(q, "created_from")
+	if err != nil {
+		return filter, err
+	}
+
+	filter.CreatedTo, err = parseTimeParam(q, "created_to")
+	if err != nil {
+		return filter, err
+	}
+
+	if cursor := q.Get("cursor"); cursor != "" {
+		filter.Cursor, err = model.ParseCursor(cursor)
+		if err != nil {
+			return filter, err
+		}
+	}
+
+	return filter, nil
+}
+
+func parseTimeParam(q url.Values, key string) (*time.Time, error) {
+	v := q.Get(key)
+	if v == "" {
+		return nil, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", key, err)
+	}
+
+	return &t, nil
 }
@@ -1,40 +1,45 @@
 package v1
 
 import (
-	"github.com/eclipsemode/go-yookassa-sdk/yookassa"
-	yoomodel "github.com/eclipsemode/go-yookassa-sdk/yookassa/model"
+	"errors"
 	"github.com/go-chi/chi/v5"
-	"github.com/go-playground/validator/v10"
+	"github.com/imperatorofdwelling/payment-svc/internal/domain/model"
+	"github.com/imperatorofdwelling/payment-svc/internal/gateway"
 	v10 "github.com/imperatorofdwelling/payment-svc/internal/lib/validator"
 	"github.com/imperatorofdwelling/payment-svc/internal/service"
+	"github.com/imperatorofdwelling/payment-svc/internal/storage/postgres"
 	"github.com/imperatorofdwelling/payment-svc/pkg/json"
 	"go.uber.org/zap"
+	"io"
 	"net/http"
 )
 
 type paymentsHandler struct {
-	svc         service.IPaymentSvc
-	log         *zap.SugaredLogger
-	yookassaHdl *yookassa.PaymentsSvc
+	svc     service.IPaymentSvc
+	log     *zap.SugaredLogger
+	gateway gateway.PaymentGateway
 }
 
-func NewPaymentsHandler(r chi.Router, svc service.IPaymentSvc, yookassaHdl *yookassa.PaymentsSvc, log *zap.SugaredLogger) {
+func NewPaymentsHandler(r chi.Router, svc service.IPaymentSvc, gateway gateway.PaymentGateway, log *zap.SugaredLogger) {
 	handler := &paymentsHandler{
-		svc:         svc,
-		log:         log,
-		yookassaHdl: yookassaHdl,
+		svc:     svc,
+		log:     log,
+		gateway: gateway,
 	}
 
 	r.Route("/payment", func(r chi.Router) {
 		r.Group(func(r chi.Router) {
 			r.Post("/", handler.createPayment)
+			r.Get("/{paymentId}", handler.getPayment)
+			r.Post("/{paymentId}/capture", handler.capturePayment)
+			r.Post("/{paymentId}/cancel", handler.cancelPayment)
 		})
 	})
 }
 
 func (h *paymentsHandler) createPayment(w http.ResponseWriter, r *http.Request) {
 	const op = "handler.v1.payments.createPayment"
-	var payment yoomodel.Payment
+	var req model.PaymentRequest
 
 	idempotenceKey := r.Header.Get("Idempotence-Key")
 	if idempotenceKey == "" {
@@ -43,46 +48,216 @@ func (h *paymentsHandler) createPayment(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	err := json.Read(r.Body, &payment)
+	err := json.Read(r.Body, &req)
 	if err != nil {
 		json.WriteError(w, http.StatusBadRequest, err.Error(), json.DecodeBodyError)
 		return
 	}
 
-	if err := v10.Validate.Struct(payment); err != nil {
-		validationErr := err.(validator.ValidationErrors)
-		json.WriteError(w, http.StatusBadRequest, validationErr.Error(), json.ValidationError)
+	if err := v10.Validate.Struct(req); err != nil {
+		writeValidationError(w, r, err)
 		return
 	}
 
-	paymentRes, err := h.yookassaHdl.CreatePayment(&payment, idempotenceKey)
+	payment, err := h.gateway.CreatePayment(r.Context(), &req, idempotenceKey)
 	if err != nil {
-		h.log.Errorf("%s: %v", op, err.Error())
-		json.WriteError(w, http.StatusBadRequest, err.Error(), json.ExternalApiError)
+		writeGatewayError(w, h.log, op, err)
 		return
 	}
 
-	var newPayment yoomodel.Payment
+	err = h.svc.CreatePayment(r.Context(), payment, idempotenceKey, model.ApiSource)
+	if err != nil {
+		h.log.Errorf("%s: %v", op, err)
+		json.WriteError(w, http.StatusInternalServerError, err.Error(), json.InternalApiError)
+		return
+	}
+
+	json.Write(w, http.StatusOK, payment)
+}
+
+// getPayment returns the stored payment. While the payment is not in a final status it is refreshed
+// from YooKassa first, unless the caller passes refresh=false.
+func (h *paymentsHandler) getPayment(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.v1.payments.getPayment"
+
+	paymentID := chi.URLParam(r, "paymentId")
+
+	payment, err := h.svc.GetPaymentByID(r.Context(), paymentID)
+	if errors.Is(err, postgres.ErrPaymentNotFound) {
+		json.WriteError(w, http.StatusNotFound, err.Error(), json.NotFoundError)
+		return
+	}
+	if err != nil {
+		h.log.Errorf("%s: %v", op, err)
+		json.WriteError(w, http.StatusInternalServerError, err.Error(), json.InternalApiError)
+		return
+	}
+
+	if r.URL.Query().Get("refresh") == "false" || model.IsFinalStatus(payment.Status) {
+		json.Write(w, http.StatusOK, payment)
+		return
+	}
+
+	refreshed, err := h.refreshPayment(r, paymentID)
+	if err != nil {
+		h.log.Warnf("%s: serving stored payment, refresh failed: %v", op, err)
+		json.Write(w, http.StatusOK, payment)
+		return
+	}
+
+	json.Write(w, http.StatusOK, refreshed)
+}
+
+func (h *paymentsHandler) refreshPayment(r *http.Request, paymentID string) (*model.Payment, error) {
+	actual, err := h.gateway.GetPayment(r.Context(), paymentID)
+	if err != nil {
+		return nil, err
+	}
+
+	return h.svc.SyncPayment(r.Context(), actual, model.ApiSource)
+}
+
+type captureReq struct {
+	Amount *model.Amount `json:"amount,omitempty" validate:"omitempty"`
+}
+
+// capturePayment confirms a two-stage payment. Without a body the full held amount is captured.
+func (h *paymentsHandler) capturePayment(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.v1.payments.capturePayment"
+
+	idempotenceKey := r.Header.Get("Idempotence-Key")
+	if idempotenceKey == "" {
+		h.log.Errorf("%s: %v", op, ErrGettingIdempotenceKey)
+		json.WriteError(w, http.StatusBadRequest, ErrGettingIdempotenceKey.Error(), json.GettingHeaderDataError)
+		return
+	}
+
+	paymentID := chi.URLParam(r, "paymentId")
+
+	var req captureReq
+
+	err := json.Read(r.Body, &req)
+	if err != nil && !errors.Is(err, io.EOF) {
+		json.WriteError(w, http.StatusBadRequest, err.Error(), json.DecodeBodyError)
+		return
+	}
+
+	if err := v10.Validate.Struct(req); err != nil {
+		writeValidationError(w, r, err)
+		return
+	}
+
+	var amount *model.Money
+
+	if req.Amount != nil {
+		money, err := req.Amount.Money()
+		if err != nil {
+			json.WriteError(w, http.StatusBadRequest, err.Error(), json.ValidationError)
+			return
+		}
+		amount = &money
+	}
+
+	ok := h.checkPaymentState(w, r, op, paymentID, func() error {
+		return h.svc.CheckCapture(r.Context(), paymentID, amount)
+	})
+	if !ok {
+		return
+	}
 
-	err = json.Read(paymentRes.Body, &newPayment)
+	actual, err := h.gateway.CapturePayment(r.Context(), paymentID, amount, idempotenceKey)
 	if err != nil {
-		h.log.Errorf("%s: %v", op, err.Error())
-		json.WriteError(w, http.StatusBadRequest, err.Error(), json.ExternalApiError)
+		writeGatewayError(w, h.log, op, err)
+		return
+	}
+
+	payment, err := h.svc.SyncPayment(r.Context(), actual, model.ApiSource)
+	if err != nil {
+		h.log.Errorf("%s: %v", op, err)
+		json.WriteError(w, http.StatusInternalServerError, err.Error(), json.InternalApiError)
+		return
+	}
+
+	json.Write(w, http.StatusOK, payment)
+}
+
+// cancelPayment releases the funds held by a two-stage payment.
+func (h *paymentsHandler) cancelPayment(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.v1.payments.cancelPayment"
+
+	idempotenceKey := r.Header.Get("Idempotence-Key")
+	if idempotenceKey == "" {
+		h.log.Errorf("%s: %v", op, ErrGettingIdempotenceKey)
+		json.WriteError(w, http.StatusBadRequest, ErrGettingIdempotenceKey.Error(), json.GettingHeaderDataError)
 		return
 	}
 
-	if newPayment.Status == "" {
-		h.log.Error("invalid response from API", zap.String("op", op), zap.String("description", newPayment.Description))
-		json.WriteError(w, http.StatusBadRequest, newPayment.Description, json.ExternalApiError)
+	paymentID := chi.URLParam(r, "paymentId")
+
+	ok := h.checkPaymentState(w, r, op, paymentID, func() error {
+		return h.svc.CheckCancel(r.Context(), paymentID)
+	})
+	if !ok {
 		return
 	}
 
-	err = h.svc.CreatePayment(r.Context(), &newPayment)
+	actual, err := h.gateway.CancelPayment(r.Context(), paymentID, idempotenceKey)
 	if err != nil {
-		h.log.Errorf("%s: %v", op, zap.Error(err))
+		writeGatewayError(w, h.log, op, err)
+		return
+	}
+
+	payment, err := h.svc.SyncPayment(r.Context(), actual, model.ApiSource)
+	if err != nil {
+		h.log.Errorf("%s: %v", op, err)
 		json.WriteError(w, http.StatusInternalServerError, err.Error(), json.InternalApiError)
 		return
 	}
 
-	json.Write(w, http.StatusOK, newPayment)
+	json.Write(w, http.StatusOK, payment)
+}
+
+// checkPaymentState runs check against the stored payment and writes the error when it fails. A payment that
+// is not final may already be held at YooKassa before the notification arrives, so it is refreshed and checked
+// again before the request is rejected.
+func (h *paymentsHandler) checkPaymentState(w http.ResponseWriter, r *http.Request, op, paymentID string, check func() error) bool {
+	err := check()
+	if errors.Is(err, service.ErrPaymentNotWaitingForCapture) {
+		stored, getErr := h.svc.GetPaymentByID(r.Context(), paymentID)
+		if getErr == nil && !model.IsFinalStatus(stored.Status) {
+			actual, gatewayErr := h.gateway.GetPayment(r.Context(), paymentID)
+			if gatewayErr != nil {
+				writeGatewayError(w, h.log, op, gatewayErr)
+				return false
+			}
+
+			_, err = h.svc.SyncPayment(r.Context(), actual, model.ApiSource)
+			if err == nil {
+				err = check()
+			}
+		}
+	}
+
+	if err != nil {
+		h.writePaymentStateError(w, op, err)
+		return false
+	}
+
+	return true
+}
+
+func (h *paymentsHandler) writePaymentStateError(w http.ResponseWriter, op string, err error) {
+	switch {
+	case errors.Is(err, postgres.ErrPaymentNotFound):
+		json.WriteError(w, http.StatusNotFound, err.Error(), json.NotFoundError)
+	case errors.Is(err, service.ErrPaymentNotWaitingForCapture):
+		json.WriteError(w, http.StatusConflict, err.Error(), json.ConflictError)
+	case errors.Is(err, service.ErrCurrencyMismatch),
+		errors.Is(err, service.ErrCaptureAmountExceeded),
+		errors.Is(err, service.ErrInvalidAmount):
+		json.WriteError(w, http.StatusBadRequest, err.Error(), json.ValidationError)
+	default:
+		h.log.Errorf("%s: %v", op, err)
+		json.WriteError(w, http.StatusInternalServerError, err.Error(), json.InternalApiError)
+	}
 }
@@ -0,0 +1,206 @@
+package v1
+
+import (
+	"errors"
+	yoomodel "github.com/eclipsemode/go-yookassa-sdk/yookassa/model"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/imperatorofdwelling/payment-svc/internal/domain/model"
+	v10 "github.com/imperatorofdwelling/payment-svc/internal/lib/validator"
+	"github.com/imperatorofdwelling/payment-svc/internal/service"
+	"github.com/imperatorofdwelling/payment-svc/internal/storage/postgres"
+	"github.com/imperatorofdwelling/payment-svc/pkg/json"
+	"go.uber.org/zap"
+	"net/http"
+)
+
+type payoutRulesHandler struct {
+	svc service.IPayoutRulesSvc
+	log *zap.SugaredLogger
+}
+
+func NewPayoutRulesHandler(r chi.Router, svc service.IPayoutRulesSvc, log *zap.SugaredLogger) {
+	handler := &payoutRulesHandler{svc, log}
+
+	r.Route("/payouts/rules", func(r chi.Router) {
+		r.Post("/", handler.createRule)
+		r.Get("/", handler.getRules)
+		r.Get("/{ruleId}", handler.getRule)
+		r.Put("/{ruleId}", handler.updateRule)
+		r.Delete("/{ruleId}", handler.deleteRule)
+	})
+}
+
+// payoutRuleReq is the body of create and update requests. UserID is ignored on update,
+// a missing enabled flag means the rule is enabled.
+type payoutRuleReq struct {
+	UserID     uuid.UUID              `json:"user_id" validate:"required"`
+	CardID     uuid.UUID              `json:"card_id" validate:"required"`
+	Period     model.PayoutRulePeriod `json:"period" validate:"required,oneof=weekly monthly"`
+	Weekday    *int                   `json:"weekday,omitempty" validate:"required_if=Period weekly,omitempty,min=0,max=6"`
+	DayOfMonth *int                   `json:"day_of_month,omitempty" validate:"required_if=Period monthly,omitempty,min=1,max=28"`
+	MinAmount  string                 `json:"min_amount" validate:"required,money"`
+	Currency   yoomodel.Currency      `json:"currency" validate:"required,iso4217,supported_currency=payouts"`
+	Enabled    *bool                  `json:"enabled,omitempty"`
+}
+
+func (req payoutRuleReq) toRule() *model.PayoutRule {
+	rule := &model.PayoutRule{
+		UserID:     req.UserID,
+		CardID:     req.CardID,
+		Period:     req.Period,
+		Weekday:    req.Weekday,
+		DayOfMonth: req.DayOfMonth,
+		MinAmount:  req.MinAmount,
+		Currency:   req.Currency,
+		Enabled:    true,
+	}
+
+	if req.Enabled != nil {
+		rule.Enabled = *req.Enabled
+	}
+
+	if rule.Period == model.WeeklyPayoutRule {
+		rule.DayOfMonth = nil
+	} else {
+		rule.Weekday = nil
+	}
+
+	return rule
+}
+
+func (h *payoutRulesHandler) createRule(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.v1.payoutrules.createRule"
+
+	req, ok := h.readRuleReq(w, r, op)
+	if !ok {
+		return
+	}
+
+	rule := req.toRule()
+
+	err := h.svc.CreatePayoutRule(r.Context(), rule)
+	if err != nil {
+		h.writeRuleError(w, op, err)
+		return
+	}
+
+	json.Write(w, http.StatusCreated, rule)
+}
+
+// getRules lists payout rules, ?user_id= narrows the list down to one user.
+func (h *payoutRulesHandler) getRules(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.v1.payoutrules.getRules"
+
+	var userID *uuid.UUID
+
+	if v := r.URL.Query().Get("user_id"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			json.WriteError(w, http.StatusBadRequest, err.Error(), json.ParseError)
+			return
+		}
+		userID = &id
+	}
+
+	rules, err := h.svc.GetPayoutRules(r.Context(), userID)
+	if err != nil {
+		h.writeRuleError(w, op, err)
+		return
+	}
+
+	json.Write(w, http.StatusOK, rules)
+}
+
+func (h *payoutRulesHandler) getRule(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.v1.payoutrules.getRule"
+
+	id, err := uuid.Parse(chi.URLParam(r, "ruleId"))
+	if err != nil {
+		json.WriteError(w, http.StatusBadRequest, err.Error(), json.ParseError)
+		return
+	}
+
+	rule, err := h.svc.GetPayoutRuleByID(r.Context(), id)
+	if err != nil {
+		h.writeRuleError(w, op, err)
+		return
+	}
+
+	json.Write(w, http.StatusOK, rule)
+}
+
+func (h *payoutRulesHandler) updateRule(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.v1.payoutrules.updateRule"
+
+	id, err := uuid.Parse(chi.URLParam(r, "ruleId"))
+	if err != nil {
+		json.WriteError(w, http.StatusBadRequest, err.Error(), json.ParseError)
+		return
+	}
+
+	req, ok := h.readRuleReq(w, r, op)
+	if !ok {
+		return
+	}
+
+	rule := req.toRule()
+	rule.ID = id
+
+	err = h.svc.UpdatePayoutRule(r.Context(), rule)
+	if err != nil {
+		h.writeRuleError(w, op, err)
+		return
+	}
+
+	json.Write(w, http.StatusOK, rule)
+}
+
+func (h *payoutRulesHandler) deleteRule(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.v1.payoutrules.deleteRule"
+
+	id, err := uuid.Parse(chi.URLParam(r, "ruleId"))
+	if err != nil {
+		json.WriteError(w, http.StatusBadRequest, err.Error(), json.ParseError)
+		return
+	}
+
+	err = h.svc.DeletePayoutRule(r.Context(), id)
+	if err != nil {
+		h.writeRuleError(w, op, err)
+		return
+	}
+
+	json.Write(w, http.StatusOK, nil)
+}
+
+func (h *payoutRulesHandler) readRuleReq(w http.ResponseWriter, r *http.Request, op string) (payoutRuleReq, bool) {
+	var req payoutRuleReq
+
+	err := json.Read(r.Body, &req)
+	if err != nil {
+		h.log.Errorf("%s: %v", op, ErrUnmarshallingBody)
+		json.WriteError(w, http.StatusBadRequest, err.Error(), json.DecodeBodyError)
+		return req, false
+	}
+
+	if err := v10.Validate.Struct(req); err != nil {
+		writeValidationError(w, r, err)
+		return req, false
+	}
+
+	return req, true
+}
+
+func (h *payoutRulesHandler) writeRuleError(w http.ResponseWriter, op string, err error) {
+	switch {
+	case errors.Is(err, postgres.ErrPayoutRuleNotFound),
+		errors.Is(err, postgres.ErrCardNotFound):
+		json.WriteError(w, http.StatusNotFound, err.Error(), json.NotFoundError)
+	case errors.Is(err, service.ErrCardNotOwnedByUser):
+		json.WriteError(w, http.StatusBadRequest, err.Error(), json.ValidationError)
+	default:
+		h.log.Errorf("%s: %v", op, err)
+		json.WriteError(w, http.StatusInternalServerError, err.Error(), json.InternalApiError)
+	}
+}
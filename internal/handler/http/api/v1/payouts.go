@@ -1,12 +1,10 @@
 package v1
 
 import (
-	"github.com/eclipsemode/go-yookassa-sdk/yookassa"
-	yoomodel "github.com/eclipsemode/go-yookassa-sdk/yookassa/model"
 	"github.com/go-chi/chi/v5"
-	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
 	"github.com/imperatorofdwelling/payment-svc/internal/domain/model"
+	"github.com/imperatorofdwelling/payment-svc/internal/gateway"
 	v10 "github.com/imperatorofdwelling/payment-svc/internal/lib/validator"
 	"github.com/imperatorofdwelling/payment-svc/internal/service"
 	"github.com/imperatorofdwelling/payment-svc/pkg/json"
@@ -15,14 +13,14 @@ import (
 )
 
 type payoutsHandler struct {
-	svc               service.IPayoutsSvc
-	cardSvc           service.ICardsSvc
-	yookassaPayoutHdl *yookassa.PayoutsSvc
-	log               *zap.SugaredLogger
+	svc     service.IPayoutsSvc
+	cardSvc service.ICardsSvc
+	gateway gateway.PayoutGateway
+	log     *zap.SugaredLogger
 }
 
-func NewPayoutsHandler(r chi.Router, svc service.IPayoutsSvc, cardSvc service.ICardsSvc, yookassaPayoutHdl *yookassa.PayoutsSvc, log *zap.SugaredLogger) {
-	handler := &payoutsHandler{svc, cardSvc, yookassaPayoutHdl, log}
+func NewPayoutsHandler(r chi.Router, svc service.IPayoutsSvc, cardSvc service.ICardsSvc, gateway gateway.PayoutGateway, log *zap.SugaredLogger) {
+	handler := &payoutsHandler{svc, cardSvc, gateway, log}
 
 	r.Route("/payouts", func(r chi.Router) {
 		r.Route("/cards", func(r chi.Router) {
@@ -84,24 +82,13 @@ func (h *payoutsHandler) getPayoutInfo(w http.ResponseWriter, r *http.Request) {
 
 	payoutID := chi.URLParam(r, "payoutId")
 
-	res, err := h.yookassaPayoutHdl.GetPayoutInfo(payoutID)
+	payoutInfo, err := h.gateway.GetPayout(r.Context(), payoutID)
 	if err != nil {
-		h.log.Errorf("%s: %v", op, err)
-		json.WriteError(w, http.StatusInternalServerError, err.Error(), json.ExternalApiError)
-		return
-	}
-
-	var payoutInfo yoomodel.Payout
-
-	err = json.Read(res.Body, &payoutInfo)
-	if err != nil {
-		h.log.Errorf("%s: %v", op, err)
-		json.WriteError(w, http.StatusBadRequest, err.Error(), json.DecodeBodyError)
+		writeGatewayError(w, h.log, op, err)
 		return
 	}
 
 	json.Write(w, http.StatusOK, payoutInfo)
-
 }
 
 func (h *payoutsHandler) makePayout(w http.ResponseWriter, r *http.Request) {
@@ -114,7 +101,7 @@ func (h *payoutsHandler) makePayout(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var newPayout yoomodel.Payout
+	var newPayout model.PayoutRequest
 
 	err := json.Read(r.Body, &newPayout)
 	if err != nil {
@@ -124,34 +111,17 @@ func (h *payoutsHandler) makePayout(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := v10.Validate.Struct(newPayout); err != nil {
-		validationErr := err.(validator.ValidationErrors)
-		json.WriteError(w, http.StatusBadRequest, validationErr.Error(), json.ValidationError)
+		writeValidationError(w, r, err)
 		return
 	}
 
-	payoutRes, err := h.yookassaPayoutHdl.MakePayout(&newPayout, idempotenceKey)
+	createdPayout, err := h.gateway.CreatePayout(r.Context(), &newPayout, idempotenceKey)
 	if err != nil {
-		h.log.Errorf("%s: %v", op, err)
-		json.WriteError(w, http.StatusInternalServerError, err.Error(), json.ExternalApiError)
-		return
-	}
-
-	var createdPayout yoomodel.Payout
-
-	err = json.Read(payoutRes.Body, &createdPayout)
-	if err != nil {
-		h.log.Errorf("%s: %v", op, err)
-		json.WriteError(w, http.StatusInternalServerError, err.Error(), json.DecodeBodyError)
-		return
-	}
-
-	if createdPayout.ID == "" {
-		h.log.Errorf("%s: %s", op, "invalid response from external api")
-		json.WriteError(w, http.StatusInternalServerError, "invalid response from external api", json.ExternalApiError)
+		writeGatewayError(w, h.log, op, err)
 		return
 	}
 
-	err = h.svc.CreatePayout(r.Context(), createdPayout)
+	err = h.svc.CreatePayout(r.Context(), createdPayout, model.ApiSource)
 	if err != nil {
 		h.log.Errorf("%s: %v", op, err)
 		json.WriteError(w, http.StatusInternalServerError, err.Error(), json.InternalApiError)
@@ -0,0 +1,81 @@
+package v1
+
+import (
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/imperatorofdwelling/payment-svc/internal/gateway"
+	"github.com/imperatorofdwelling/payment-svc/internal/service"
+	"github.com/imperatorofdwelling/payment-svc/internal/storage/postgres"
+	"github.com/imperatorofdwelling/payment-svc/pkg/json"
+	"go.uber.org/zap"
+	"net/http"
+	"time"
+)
+
+type reconciliationHandler struct {
+	svc service.IReconciliationSvc
+	log *zap.SugaredLogger
+}
+
+func NewReconciliationHandler(r chi.Router, svc service.IReconciliationSvc, log *zap.SugaredLogger) {
+	handler := &reconciliationHandler{svc, log}
+
+	r.Route("/reconciliation", func(r chi.Router) {
+		r.Get("/{date}", handler.getReport)
+		r.Post("/{date}", handler.reconcile)
+	})
+}
+
+// getReport returns the stored report for a UTC day given as YYYY-MM-DD.
+func (h *reconciliationHandler) getReport(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.v1.reconciliation.getReport"
+
+	date, err := time.Parse(time.DateOnly, chi.URLParam(r, "date"))
+	if err != nil {
+		json.WriteError(w, http.StatusBadRequest, err.Error(), json.ParseError)
+		return
+	}
+
+	report, err := h.svc.GetReportByDate(r.Context(), date)
+	if errors.Is(err, postgres.ErrReportNotFound) {
+		json.WriteError(w, http.StatusNotFound, err.Error(), json.NotFoundError)
+		return
+	}
+	if err != nil {
+		h.log.Errorf("%s: %v", op, err)
+		json.WriteError(w, http.StatusInternalServerError, err.Error(), json.InternalApiError)
+		return
+	}
+
+	json.Write(w, http.StatusOK, report)
+}
+
+// reconcile runs the reconciliation for a day right away, replacing its stored report.
+func (h *reconciliationHandler) reconcile(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.v1.reconciliation.reconcile"
+
+	date, err := time.Parse(time.DateOnly, chi.URLParam(r, "date"))
+	if err != nil {
+		json.WriteError(w, http.StatusBadRequest, err.Error(), json.ParseError)
+		return
+	}
+
+	report, err := h.svc.Reconcile(r.Context(), date)
+	if errors.Is(err, gateway.ErrUnavailable) {
+		h.log.Errorf("%s: %v", op, err)
+		json.WriteError(w, http.StatusServiceUnavailable, err.Error(), json.ProviderUnavailableError)
+		return
+	}
+	if errors.Is(err, gateway.ErrRejected) || errors.Is(err, gateway.ErrInvalidResponse) {
+		h.log.Errorf("%s: %v", op, err)
+		json.WriteError(w, http.StatusBadGateway, err.Error(), json.ExternalApiError)
+		return
+	}
+	if err != nil {
+		h.log.Errorf("%s: %v", op, err)
+		json.WriteError(w, http.StatusInternalServerError, err.Error(), json.InternalApiError)
+		return
+	}
+
+	json.Write(w, http.StatusOK, report)
+}
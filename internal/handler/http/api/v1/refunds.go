@@ -0,0 +1,121 @@
+package v1
+
+import (
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/imperatorofdwelling/payment-svc/internal/domain/model"
+	"github.com/imperatorofdwelling/payment-svc/internal/gateway"
+	v10 "github.com/imperatorofdwelling/payment-svc/internal/lib/validator"
+	"github.com/imperatorofdwelling/payment-svc/internal/service"
+	"github.com/imperatorofdwelling/payment-svc/internal/storage/postgres"
+	"github.com/imperatorofdwelling/payment-svc/pkg/json"
+	"go.uber.org/zap"
+	"net/http"
+)
+
+type refundsHandler struct {
+	svc     service.IRefundsSvc
+	gateway gateway.PaymentGateway
+	log     *zap.SugaredLogger
+}
+
+func NewRefundsHandler(r chi.Router, svc service.IRefundsSvc, gateway gateway.PaymentGateway, log *zap.SugaredLogger) {
+	handler := &refundsHandler{svc, gateway, log}
+
+	r.Route("/payment/{paymentId}/refunds", func(r chi.Router) {
+		r.Post("/", handler.createRefund)
+		r.Get("/", handler.getRefunds)
+	})
+}
+
+func (h *refundsHandler) createRefund(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.v1.refunds.createRefund"
+
+	idempotenceKey := r.Header.Get("Idempotence-Key")
+	if idempotenceKey == "" {
+		h.log.Errorf("%s: %v", op, ErrGettingIdempotenceKey)
+		json.WriteError(w, http.StatusBadRequest, ErrGettingIdempotenceKey.Error(), json.GettingHeaderDataError)
+		return
+	}
+
+	var req model.RefundRequest
+
+	err := json.Read(r.Body, &req)
+	if err != nil {
+		h.log.Errorf("%s: %v", op, ErrUnmarshallingBody)
+		json.WriteError(w, http.StatusBadRequest, err.Error(), json.DecodeBodyError)
+		return
+	}
+
+	req.PaymentID = chi.URLParam(r, "paymentId")
+
+	if err := v10.Validate.Struct(req); err != nil {
+		writeValidationError(w, r, err)
+		return
+	}
+
+	amount, err := req.Amount.Money()
+	if err != nil {
+		json.WriteError(w, http.StatusBadRequest, err.Error(), json.ValidationError)
+		return
+	}
+
+	release, err := h.svc.LockRefunds(r.Context(), req.PaymentID)
+	if err != nil {
+		h.writeRefundError(w, op, err)
+		return
+	}
+
+	defer release()
+
+	err = h.svc.CheckRefund(r.Context(), req.PaymentID, amount)
+	if err != nil {
+		h.writeRefundError(w, op, err)
+		return
+	}
+
+	createdRefund, err := h.gateway.CreateRefund(r.Context(), &req, idempotenceKey)
+	if err != nil {
+		writeGatewayError(w, h.log, op, err)
+		return
+	}
+
+	refund, err := h.svc.CreateRefund(r.Context(), createdRefund, idempotenceKey, model.ApiSource)
+	if err != nil {
+		h.log.Errorf("%s: %v", op, err)
+		json.WriteError(w, http.StatusInternalServerError, err.Error(), json.InternalApiError)
+		return
+	}
+
+	json.Write(w, http.StatusOK, refund)
+}
+
+func (h *refundsHandler) getRefunds(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.v1.refunds.getRefunds"
+
+	paymentID := chi.URLParam(r, "paymentId")
+
+	refunds, err := h.svc.GetRefundsByPaymentID(r.Context(), paymentID)
+	if err != nil {
+		h.writeRefundError(w, op, err)
+		return
+	}
+
+	json.Write(w, http.StatusOK, refunds)
+}
+
+func (h *refundsHandler) writeRefundError(w http.ResponseWriter, op string, err error) {
+	switch {
+	case errors.Is(err, postgres.ErrPaymentNotFound):
+		json.WriteError(w, http.StatusNotFound, err.Error(), json.NotFoundError)
+	case errors.Is(err, service.ErrPaymentNotRefundable):
+		json.WriteError(w, http.StatusConflict, err.Error(), json.ConflictError)
+	case errors.Is(err, service.ErrCurrencyMismatch),
+		errors.Is(err, service.ErrRefundAmountExceeded),
+		errors.Is(err, service.ErrInvalidAmount):
+		json.WriteError(w, http.StatusBadRequest, err.Error(), json.ValidationError)
+	default:
+		h.log.Errorf("%s: %v", op, err)
+		json.WriteError(w, http.StatusInternalServerError, err.Error(), json.InternalApiError)
+	}
+}
@@ -0,0 +1,133 @@
+package v1
+
+import (
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/imperatorofdwelling/payment-svc/internal/domain/model"
+	v10 "github.com/imperatorofdwelling/payment-svc/internal/lib/validator"
+	"github.com/imperatorofdwelling/payment-svc/internal/service"
+	"github.com/imperatorofdwelling/payment-svc/internal/storage/postgres"
+	"github.com/imperatorofdwelling/payment-svc/pkg/json"
+	"go.uber.org/zap"
+	"net/http"
+	"time"
+)
+
+type scheduledPayoutsHandler struct {
+	svc service.IScheduledPayoutsSvc
+	log *zap.SugaredLogger
+}
+
+func NewScheduledPayoutsHandler(r chi.Router, svc service.IScheduledPayoutsSvc, log *zap.SugaredLogger) {
+	handler := &scheduledPayoutsHandler{svc, log}
+
+	r.Route("/payouts/scheduled", func(r chi.Router) {
+		r.Post("/", handler.schedulePayout)
+		r.Get("/", handler.getScheduledPayouts)
+		r.Get("/{id}", handler.getScheduledPayout)
+		r.Delete("/{id}", handler.cancelScheduledPayout)
+	})
+}
+
+// scheduledPayoutReq is the regular payout body with the execution time added.
+type scheduledPayoutReq struct {
+	model.PayoutRequest
+	ExecuteAt time.Time `json:"execute_at" validate:"required"`
+}
+
+func (h *scheduledPayoutsHandler) schedulePayout(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.v1.scheduledpayouts.schedulePayout"
+
+	var req scheduledPayoutReq
+
+	err := json.Read(r.Body, &req)
+	if err != nil {
+		h.log.Errorf("%s: %v", op, ErrUnmarshallingBody)
+		json.WriteError(w, http.StatusBadRequest, err.Error(), json.DecodeBodyError)
+		return
+	}
+
+	if err := v10.Validate.Struct(req); err != nil {
+		writeValidationError(w, r, err)
+		return
+	}
+
+	scheduled, err := h.svc.SchedulePayout(r.Context(), req.PayoutRequest, req.ExecuteAt)
+	if errors.Is(err, service.ErrExecuteAtInPast) {
+		json.WriteError(w, http.StatusBadRequest, err.Error(), json.ValidationError)
+		return
+	}
+	if err != nil {
+		h.log.Errorf("%s: %v", op, err)
+		json.WriteError(w, http.StatusInternalServerError, err.Error(), json.InternalApiError)
+		return
+	}
+
+	json.Write(w, http.StatusCreated, scheduled)
+}
+
+// getScheduledPayouts lists scheduled payouts, ?status=pending narrows the list down to one status.
+func (h *scheduledPayoutsHandler) getScheduledPayouts(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.v1.scheduledpayouts.getScheduledPayouts"
+
+	status := model.ScheduledPayoutStatus(r.URL.Query().Get("status"))
+
+	payouts, err := h.svc.GetScheduledPayouts(r.Context(), status)
+	if err != nil {
+		h.log.Errorf("%s: %v", op, err)
+		json.WriteError(w, http.StatusInternalServerError, err.Error(), json.InternalApiError)
+		return
+	}
+
+	json.Write(w, http.StatusOK, payouts)
+}
+
+func (h *scheduledPayoutsHandler) getScheduledPayout(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.v1.scheduledpayouts.getScheduledPayout"
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		json.WriteError(w, http.StatusBadRequest, err.Error(), json.ParseError)
+		return
+	}
+
+	scheduled, err := h.svc.GetScheduledPayoutByID(r.Context(), id)
+	if errors.Is(err, postgres.ErrScheduledPayoutNotFound) {
+		json.WriteError(w, http.StatusNotFound, err.Error(), json.NotFoundError)
+		return
+	}
+	if err != nil {
+		h.log.Errorf("%s: %v", op, err)
+		json.WriteError(w, http.StatusInternalServerError, err.Error(), json.InternalApiError)
+		return
+	}
+
+	json.Write(w, http.StatusOK, scheduled)
+}
+
+func (h *scheduledPayoutsHandler) cancelScheduledPayout(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.v1.scheduledpayouts.cancelScheduledPayout"
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		json.WriteError(w, http.StatusBadRequest, err.Error(), json.ParseError)
+		return
+	}
+
+	err = h.svc.CancelScheduledPayout(r.Context(), id)
+	switch {
+	case errors.Is(err, postgres.ErrScheduledPayoutNotFound):
+		json.WriteError(w, http.StatusNotFound, err.Error(), json.NotFoundError)
+		return
+	case errors.Is(err, service.ErrScheduledPayoutNotCancelable):
+		json.WriteError(w, http.StatusConflict, err.Error(), json.ConflictError)
+		return
+	case err != nil:
+		h.log.Errorf("%s: %v", op, err)
+		json.WriteError(w, http.StatusInternalServerError, err.Error(), json.InternalApiError)
+		return
+	}
+
+	json.Write(w, http.StatusOK, nil)
+}
@@ -0,0 +1,115 @@
+package v1
+
+import (
+	"fmt"
+	yoomodel "github.com/eclipsemode/go-yookassa-sdk/yookassa/model"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/imperatorofdwelling/payment-svc/internal/domain/model"
+	v10 "github.com/imperatorofdwelling/payment-svc/internal/lib/validator"
+	"github.com/imperatorofdwelling/payment-svc/internal/service"
+	"github.com/imperatorofdwelling/payment-svc/pkg/json"
+	"go.uber.org/zap"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+type usersHandler struct {
+	ledgerSvc service.ILedgerSvc
+	log       *zap.SugaredLogger
+}
+
+func NewUsersHandler(r chi.Router, ledgerSvc service.ILedgerSvc, log *zap.SugaredLogger) {
+	handler := &usersHandler{ledgerSvc, log}
+
+	r.Route("/users/{userId}", func(r chi.Router) {
+		r.Get("/balance", handler.getBalance)
+		r.Get("/ledger", handler.getLedger)
+	})
+}
+
+// getBalance returns the user balances in every currency. A user without any postings has no balances.
+func (h *usersHandler) getBalance(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.v1.users.getBalance"
+
+	userID, err := uuid.Parse(chi.URLParam(r, "userId"))
+	if err != nil {
+		json.WriteError(w, http.StatusBadRequest, err.Error(), json.ParseError)
+		return
+	}
+
+	user, err := h.ledgerSvc.GetUser(r.Context(), userID)
+	if err != nil {
+		h.log.Errorf("%s: %v", op, err)
+		json.WriteError(w, http.StatusInternalServerError, err.Error(), json.InternalApiError)
+		return
+	}
+
+	json.Write(w, http.StatusOK, user)
+}
+
+// getLedger lists the user balance changes filtered by query parameters: currency, order (asc or desc,
+// newest first by default), limit and cursor.
+func (h *usersHandler) getLedger(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.v1.users.getLedger"
+
+	userID, err := uuid.Parse(chi.URLParam(r, "userId"))
+	if err != nil {
+		json.WriteError(w, http.StatusBadRequest, err.Error(), json.ParseError)
+		return
+	}
+
+	filter, err := parseLedgerFilter(r.URL.Query())
+	if err != nil {
+		json.WriteError(w, http.StatusBadRequest, err.Error(), json.ParseError)
+		return
+	}
+
+	filter.UserID = userID
+
+	if err := v10.Validate.Struct(filter); err != nil {
+		writeValidationError(w, r, err)
+		return
+	}
+
+	page, err := h.ledgerSvc.GetLedger(r.Context(), filter)
+	if err != nil {
+		h.log.Errorf("%s: %v", op, err)
+		json.WriteError(w, http.StatusInternalServerError, err.Error(), json.InternalApiError)
+		return
+	}
+
+	json.Write(w, http.StatusOK, page)
+}
+
+func parseLedgerFilter(q url.Values) (model.LedgerFilter, error) {
+	filter := model.LedgerFilter{
+		Currency: yoomodel.Currency(q.Get("currency")),
+		Order:    model.SortDesc,
+		Limit:    defaultLogsLimit,
+	}
+
+	if order := q.Get("order"); order != "" {
+		filter.Order = model.SortOrder(order)
+	}
+
+	if limit := q.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil {
+			return filter, fmt.Errorf("invalid limit: %w", err)
+		}
+		filter.Limit = n
+	}
+
+	if cursor := q.Get("cursor"); cursor != "" {
+		var err error
+
+		filter.Cursor, err = model.ParseCursor(cursor)
+		if err != nil {
+			return filter, err
+		}
+	}
+
+	return filter, nil
+}
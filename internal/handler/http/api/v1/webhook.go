@@ -0,0 +1,68 @@
+package v1
+
+import (
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/imperatorofdwelling/payment-svc/internal/config"
+	"github.com/imperatorofdwelling/payment-svc/internal/domain/model"
+	v10 "github.com/imperatorofdwelling/payment-svc/internal/lib/validator"
+	"github.com/imperatorofdwelling/payment-svc/internal/service"
+	"github.com/imperatorofdwelling/payment-svc/pkg/json"
+	"go.uber.org/zap"
+	"net/http"
+)
+
+type webhookHandler struct {
+	svc service.INotificationSvc
+	log *zap.SugaredLogger
+}
+
+// NewWebhookHandler mounts the YooKassa notification receiver. The allowlist checks the peer address,
+// so r must not use middleware.RealIP.
+func NewWebhookHandler(r chi.Router, svc service.INotificationSvc, cfgWebhook config.Webhook, log *zap.SugaredLogger) {
+	handler := &webhookHandler{svc: svc, log: log}
+
+	allowlist, err := newIPAllowlist(cfgWebhook.AllowedIPs, cfgWebhook.TrustedProxies, log)
+	if err != nil {
+		log.Fatalf("invalid webhook config: %v", err)
+	}
+
+	r.With(allowlist).Post("/logs/status", handler.changeStatus)
+}
+
+// changeStatus receives YooKassa notifications. The response code tells YooKassa whether to redeliver:
+// anything but 200 is retried, so permanent rejections use 4xx and transient failures use 5xx.
+func (h *webhookHandler) changeStatus(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.v1.webhook.changeStatus"
+
+	var notification model.Notification
+
+	err := json.Read(r.Body, &notification)
+	if err != nil {
+		h.log.Errorf("%s: %v", op, err)
+		json.WriteError(w, http.StatusBadRequest, err.Error(), json.DecodeBodyError)
+		return
+	}
+
+	if err := v10.Validate.Struct(notification); err != nil {
+		writeValidationError(w, r, err)
+		return
+	}
+
+	err = h.svc.HandleNotification(r.Context(), notification)
+	switch {
+	case errors.Is(err, service.ErrUnknownNotificationEvent):
+		json.WriteError(w, http.StatusBadRequest, err.Error(), json.ValidationError)
+		return
+	case errors.Is(err, service.ErrNotificationNotVerified):
+		h.log.Warnf("%s: %v", op, err)
+		json.WriteError(w, http.StatusForbidden, err.Error(), json.AuthorizationError)
+		return
+	case err != nil:
+		h.log.Errorf("%s: %v", op, err)
+		json.WriteError(w, http.StatusInternalServerError, err.Error(), json.InternalApiError)
+		return
+	}
+
+	json.Write(w, http.StatusOK, nil)
+}
@@ -2,9 +2,11 @@ package http
 
 import (
 	"errors"
+	"expvar"
 	"fmt"
 	"github.com/imperatorofdwelling/payment-svc/internal/config"
 	"github.com/imperatorofdwelling/payment-svc/internal/lib/scheduler"
+	"github.com/imperatorofdwelling/payment-svc/internal/service"
 	"go.uber.org/zap"
 	"net/http"
 	"os"
@@ -13,8 +15,9 @@ import (
 )
 
 type Server struct {
-	Srv *http.Server
-	Log *zap.SugaredLogger
+	Srv   *http.Server
+	Debug *http.Server
+	Log   *zap.SugaredLogger
 }
 
 func NewServer(cfgServer config.Server, handler http.Handler, log *zap.SugaredLogger) *Server {
@@ -25,9 +28,22 @@ func NewServer(cfgServer config.Server, handler http.Handler, log *zap.SugaredLo
 		Handler: handler,
 	}
 
+	// metrics reveal the command line and internals of the service, so they are kept off the public listener
+	var debug *http.Server
+	if cfgServer.DebugAddr != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/debug/vars", expvar.Handler())
+
+		debug = &http.Server{
+			Addr:    cfgServer.DebugAddr,
+			Handler: mux,
+		}
+	}
+
 	return &Server{
-		Srv: srv,
-		Log: log,
+		Srv:   srv,
+		Debug: debug,
+		Log:   log,
 	}
 }
 
@@ -40,16 +56,27 @@ func (s *Server) Start() error {
 		}
 	}()
 
+	if s.Debug != nil {
+		s.Log.Infof("debug server listening at %s", s.Debug.Addr)
+
+		go func() {
+			if err := s.Debug.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				s.Log.Error("error starting debug server:", err)
+			}
+		}()
+	}
+
 	return nil
 }
 
-func (s *Server) Stop(scheduler *scheduler.Scheduler) {
+func (s *Server) Stop(scheduler *scheduler.Scheduler, payoutSubscriber *service.PayoutSubscriber) {
 	quit := make(chan os.Signal, 1)
 
-	scheduler.Stop()
-
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	sign := <-quit
 
+	scheduler.Stop()
+	payoutSubscriber.Stop()
+
 	s.Log.Infof("server successfully stopped received signal %s", sign.String())
 }
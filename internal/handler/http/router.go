@@ -1,14 +1,16 @@
 package http
 
 import (
-	"github.com/eclipsemode/go-yookassa-sdk/yookassa"
+	"context"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/imperatorofdwelling/payment-svc/internal/config"
+	"github.com/imperatorofdwelling/payment-svc/internal/gateway"
 	v1 "github.com/imperatorofdwelling/payment-svc/internal/handler/http/api/v1"
 	"github.com/imperatorofdwelling/payment-svc/internal/handler/http/htmx"
 	kafka "github.com/imperatorofdwelling/payment-svc/internal/handler/kafka/consumer"
 	consumer "github.com/imperatorofdwelling/payment-svc/internal/handler/kafka/consumer/payment"
+	"github.com/imperatorofdwelling/payment-svc/internal/lib/scheduler"
 	"github.com/imperatorofdwelling/payment-svc/internal/service"
 	"github.com/imperatorofdwelling/payment-svc/internal/storage"
 	"github.com/imperatorofdwelling/payment-svc/internal/storage/postgres"
@@ -17,56 +19,112 @@ import (
 	"time"
 )
 
+// requestTimeout bounds every API request except the logs export.
+const requestTimeout = 60 * time.Second
+
 type Router struct {
 	Handler *chi.Mux
+
+	PayoutSubscriber *service.PayoutSubscriber
 }
 
-func NewRouter(s *storage.Storage, log *zap.SugaredLogger, cfg *config.Config) *Router {
+func NewRouter(s *storage.Storage, sched *scheduler.Scheduler, log *zap.SugaredLogger, cfg *config.Config) *Router {
 	r := chi.NewRouter()
 
+	var payoutSubscriber *service.PayoutSubscriber
+
 	r.Use(middleware.RequestID)
-	r.Use(middleware.RealIP)
 	r.Use(middleware.Logger)
 	r.Use(middleware.Recoverer)
 	r.Use(middleware.URLFormat)
-	r.Use(middleware.Timeout(60 * time.Second))
 
 	r.Route("/api/v1", func(r chi.Router) {
+		var (
+			notificationSvc service.INotificationSvc
+			logsSvc         service.ILogsSvc
+		)
+
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.RealIP)
+			r.Use(middleware.Timeout(requestTimeout))
+
+			rdbIdempotencyRepo := redis.NewIdempotencyRepo(s.Redis)
+			r.Use(v1.NewIdempotency(rdbIdempotencyRepo, cfg.Idempotency, log.Named("idempotency")))
+
+			paymentGateway, payoutGateway, err := gateway.New(cfg, log.Named("gateway"))
+			if err != nil {
+				log.Fatalf("invalid gateway config: %v", err)
+			}
+
+			cardsRepo := postgres.NewCardsRepo(s.Psql, log.Named("cards_repo"))
+			cardsSvc := service.NewCardsService(cardsRepo, log.Named("cards_service"))
+
+			logsRepo := postgres.NewLogsRepo(s.Psql, log.Named("logs_repo"))
+			logsSvc = service.NewLogsService(logsRepo, log.Named("logs_service"))
+
+			ledgerRepo := postgres.NewLedgerRepo(s.Psql, log.Named("ledger_repo"))
+			ledgerSvc := service.NewLedgerService(ledgerRepo, log.Named("ledger_service"))
+			v1.NewUsersHandler(r, ledgerSvc, log.Named("users_handler"))
+
+			paymentRepo := postgres.NewPaymentRepo(s.Psql, log.Named("payment_repo"))
+			paymentSvc := service.NewPaymentSvc(paymentRepo, logsSvc, ledgerSvc, log.Named("payment_service"))
+			v1.NewPaymentsHandler(r, paymentSvc, paymentGateway, log.Named("payment_handler"))
+
+			refundsRepo := postgres.NewRefundsRepo(s.Psql, log.Named("refunds_repo"))
+			refundsSvc := service.NewRefundsService(refundsRepo, paymentRepo, logsSvc, ledgerSvc, log.Named("refunds_service"))
+			v1.NewRefundsHandler(r, refundsSvc, paymentGateway, log.Named("refunds_handler"))
+
+			payoutsRepo := postgres.NewPayoutsRepo(s.Psql, log.Named("payouts_repo"))
+
+			statusSyncRepo := postgres.NewStatusSyncRepo(s.Psql, log.Named("status_sync_repo"))
+			payoutSubscriber = service.NewPayoutSubscriber(statusSyncRepo, payoutsRepo, logsSvc, ledgerSvc, payoutGateway, cfg.StatusSync, log.Named("payout_subscriber"))
 
-		rdbTransactionsRepo := redis.NewTransactionRepo(s.Redis)
+			payoutsSvc := service.NewPayoutsService(payoutsRepo, cardsSvc, payoutSubscriber, logsSvc, ledgerSvc, log.Named("payouts_service"))
+			v1.NewPayoutsHandler(r, payoutsSvc, cardsSvc, payoutGateway, log.Named("payout_handler"))
 
-		yooClient := yookassa.NewYookassaClient(cfg.PayApi.ShopID, cfg.PayApi.SecretKey, cfg.PayApi.PayoutAgentID, cfg.PayApi.PayoutSecretKey)
-		yookassaPaymentsSvc := yookassa.NewPaymentsService(yooClient, log.Named("yookassa_handler"))
-		yookassaPayoutsSvc := yookassa.NewPayoutsService(yooClient, log.Named("yookassa_handler"))
+			scheduledPayoutsRepo := postgres.NewScheduledPayoutsRepo(s.Psql, log.Named("scheduled_payouts_repo"))
+			scheduledPayoutsSvc := service.NewScheduledPayoutsService(scheduledPayoutsRepo, payoutsSvc, payoutGateway, sched, log.Named("scheduled_payouts_service"))
+			v1.NewScheduledPayoutsHandler(r, scheduledPayoutsSvc, log.Named("scheduled_payouts_handler"))
 
-		cardsRepo := postgres.NewCardsRepo(s.Psql, log.Named("cards_repo"))
-		cardsSvc := service.NewCardsService(cardsRepo, log.Named("cards_service"))
+			payoutRulesRepo := postgres.NewPayoutRulesRepo(s.Psql, log.Named("payout_rules_repo"))
+			payoutRulesSvc := service.NewPayoutRulesService(payoutRulesRepo, ledgerSvc, cardsSvc, payoutsSvc, payoutGateway, sched, log.Named("payout_rules_service"))
+			v1.NewPayoutRulesHandler(r, payoutRulesSvc, log.Named("payout_rules_handler"))
 
-		logsRepo := postgres.NewLogsRepo(s.Psql, log.Named("logs_repo"))
-		logsSvc := service.NewLogsService(logsRepo, log.Named("logs_service"))
-		v1.NewLogsHandler(r, logsSvc, log.Named("logs_handler"))
+			rdbNotificationsRepo := redis.NewNotificationRepo(s.Redis)
+			notificationSvc = service.NewNotificationService(rdbNotificationsRepo, paymentGateway, payoutGateway, paymentSvc, refundsSvc, payoutsSvc, cfg.Webhook.DedupTTL, log.Named("notification_service"))
+			v1.NewLogsHandler(r, logsSvc, log.Named("logs_handler"))
 
-		paymentRepo := postgres.NewPaymentRepo(s.Psql, log.Named("payment_repo"))
-		paymentSvc := service.NewPaymentSvc(paymentRepo, logsSvc, log.Named("payment_service"))
-		v1.NewPaymentsHandler(r, paymentSvc, yookassaPaymentsSvc, log.Named("payment_handler"))
+			reconciliationRepo := postgres.NewReconciliationRepo(s.Psql, log.Named("reconciliation_repo"))
+			reconciliationSvc := service.NewReconciliationService(reconciliationRepo, logsSvc, paymentGateway, payoutGateway, log.Named("reconciliation_service"))
+			v1.NewReconciliationHandler(r, reconciliationSvc, log.Named("reconciliation_handler"))
 
-		payoutSubscriber := service.NewPayoutSubscriber(rdbTransactionsRepo, logsSvc, yookassaPayoutsSvc)
+			_, err = sched.Create("reconciliation", cfg.Reconciliation.Schedule, func() {
+				if _, err := reconciliationSvc.Reconcile(context.Background(), time.Now().AddDate(0, 0, -1)); err != nil {
+					log.Errorf("daily reconciliation failed: %v", err)
+				}
+			})
+			if err != nil {
+				log.Fatalf("invalid reconciliation config: %v", err)
+			}
 
-		payoutsRepo := postgres.NewPayoutsRepo(s.Psql, log.Named("payouts_repo"))
-		payoutsSvc := service.NewPayoutsService(payoutsRepo, payoutSubscriber, logsSvc, log.Named("payouts_service"))
-		v1.NewPayoutsHandler(r, payoutsSvc, cardsSvc, yookassaPayoutsSvc, log.Named("payout_handler"))
+			kafkaProducer := kafka.NewKafkaProducer(log.Named("kafka_producer"))
 
-		kafkaProducer := kafka.NewKafkaProducer(log.Named("kafka_producer"))
+			paymentConsumer := consumer.NewPaymentConsumer(log.Named("kafka_payment_consumer"), paymentGateway, paymentSvc, kafkaProducer)
 
-		paymentConsumer := consumer.NewPaymentConsumer(log.Named("kafka_payment_consumer"), yookassaPaymentsSvc, paymentSvc, kafkaProducer)
+			kafka.SetupKafkaConsumers(paymentConsumer)
 
-		kafka.SetupKafkaConsumers(paymentConsumer)
+			htmx.NewHTMXHandler(r, log.Named("htmx_handler"))
+		})
 
-		htmx.NewHTMXHandler(r, log.Named("htmx_handler"))
+		// the webhook allowlist checks the peer address, which RealIP would take from client headers
+		v1.NewWebhookHandler(r.With(middleware.Timeout(requestTimeout)), notificationSvc, cfg.Webhook, log.Named("webhook_handler"))
 
+		// a month of logs takes longer than requestTimeout to stream, and a cut off export would still look complete
+		v1.NewLogsExportHandler(r.With(middleware.RealIP), logsSvc, log.Named("logs_handler"))
 	})
 
 	return &Router{
-		Handler: r,
+		Handler:          r,
+		PayoutSubscriber: payoutSubscriber,
 	}
 }
@@ -4,22 +4,21 @@ import (
 	jsonDefault "encoding/json"
 	"fmt"
 	"github.com/IBM/sarama"
-	"github.com/eclipsemode/go-yookassa-sdk/yookassa"
-	yoomodel "github.com/eclipsemode/go-yookassa-sdk/yookassa/model"
 	"github.com/go-playground/validator/v10"
+	"github.com/imperatorofdwelling/payment-svc/internal/domain/model"
+	"github.com/imperatorofdwelling/payment-svc/internal/gateway"
 	kafka "github.com/imperatorofdwelling/payment-svc/internal/handler/kafka/consumer"
 	v10 "github.com/imperatorofdwelling/payment-svc/internal/lib/validator"
 	"github.com/imperatorofdwelling/payment-svc/internal/service"
-	"github.com/imperatorofdwelling/payment-svc/pkg/json"
 	"go.uber.org/zap"
 )
 
 type PaymentConsumer struct {
 	// TODO fix bug with using log (crushing the app)
-	log                *zap.SugaredLogger
-	yookassaPaymentSvc *yookassa.PaymentsSvc
-	paymentSvc         service.IPaymentSvc
-	kafkaProducer      *kafka.Producer
+	log            *zap.SugaredLogger
+	paymentGateway gateway.PaymentGateway
+	paymentSvc     service.IPaymentSvc
+	kafkaProducer  *kafka.Producer
 }
 
 func (*PaymentConsumer) Setup(sarama.ConsumerGroupSession) error   { return nil }
@@ -32,7 +31,7 @@ func (c *PaymentConsumer) ConsumeClaim(
 	for msg := range claim.Messages() {
 		requestID := string(msg.Key)
 
-		var payment yoomodel.Payment
+		var payment model.PaymentRequest
 
 		err := jsonDefault.Unmarshal(msg.Value, &payment)
 		if err != nil {
@@ -44,23 +43,12 @@ func (c *PaymentConsumer) ConsumeClaim(
 			return fmt.Errorf("%s: %w", "error validating payment fields", validationErr)
 		}
 
-		paymentRes, err := c.yookassaPaymentSvc.CreatePayment(&payment, requestID)
+		newPayment, err := c.paymentGateway.CreatePayment(sess.Context(), &payment, requestID)
 		if err != nil {
 			return fmt.Errorf("%s: %s", op, err.Error())
 		}
 
-		var newPayment yoomodel.Payment
-
-		err = json.Read(paymentRes.Body, &newPayment)
-		if err != nil {
-			return fmt.Errorf("%s: %s", op, err.Error())
-		}
-
-		if newPayment.Status == "" {
-			return fmt.Errorf("%s: %s", op, "error creating payment")
-		}
-
-		err = c.paymentSvc.CreatePayment(sess.Context(), &newPayment)
+		err = c.paymentSvc.CreatePayment(sess.Context(), newPayment, requestID, model.KafkaSource)
 		if err != nil {
 			return fmt.Errorf("%s: %s", op, err.Error())
 		}
@@ -75,8 +63,8 @@ func (c *PaymentConsumer) ConsumeClaim(
 	return nil
 }
 
-func NewPaymentConsumer(log *zap.SugaredLogger, yookassaPaymentSvc *yookassa.PaymentsSvc, paymentSvc service.IPaymentSvc, kafkaProducer *kafka.Producer) *PaymentConsumer {
+func NewPaymentConsumer(log *zap.SugaredLogger, paymentGateway gateway.PaymentGateway, paymentSvc service.IPaymentSvc, kafkaProducer *kafka.Producer) *PaymentConsumer {
 	return &PaymentConsumer{
-		log, yookassaPaymentSvc, paymentSvc, kafkaProducer,
+		log, paymentGateway, paymentSvc, kafkaProducer,
 	}
 }
@@ -0,0 +1,135 @@
+// Package breaker is a circuit breaker for calls to external services. After threshold failures in a row
+// the breaker opens and calls fail fast for the cooldown, then a single trial call decides whether it
+// closes again or stays open for another cooldown.
+package breaker
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+var ErrOpen = errors.New("circuit breaker is open")
+
+type State string
+
+const (
+	Closed   State = "closed"
+	Open     State = "open"
+	HalfOpen State = "half_open"
+)
+
+type Breaker struct {
+	threshold int
+	cooldown  time.Duration
+	onChange  func(from, to State)
+
+	mu       sync.Mutex
+	state    State
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// New creates a closed breaker. A threshold below 1 disables it. onChange, if set, is called on every
+// state change while the breaker is locked, so it must not call the breaker.
+func New(threshold int, cooldown time.Duration, onChange func(from, to State)) *Breaker {
+	return &Breaker{threshold: threshold, cooldown: cooldown, onChange: onChange, state: Closed}
+}
+
+// Allow reports whether a call may be made. Every allowed call must be followed by Success, Failure
+// or Ignore.
+func (b *Breaker) Allow() error {
+	if b.threshold < 1 {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case Open:
+		if time.Since(b.openedAt) < b.cooldown {
+			return ErrOpen
+		}
+
+		b.setState(HalfOpen)
+		b.probing = true
+
+		return nil
+	case HalfOpen:
+		if b.probing {
+			return ErrOpen
+		}
+
+		b.probing = true
+
+		return nil
+	default:
+		return nil
+	}
+}
+
+// Success records a call answered by the service, which closes the breaker.
+func (b *Breaker) Success() {
+	if b.threshold < 1 {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures = 0
+	b.probing = false
+	b.setState(Closed)
+}
+
+// Failure records a call the service did not answer properly.
+func (b *Breaker) Failure() {
+	if b.threshold < 1 {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+	b.failures++
+
+	if b.state == HalfOpen || b.failures >= b.threshold {
+		b.openedAt = time.Now()
+		b.setState(Open)
+	}
+}
+
+// Ignore records a call that says nothing about the service, e.g. one canceled by the caller.
+func (b *Breaker) Ignore() {
+	if b.threshold < 1 {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+}
+
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.state
+}
+
+func (b *Breaker) setState(state State) {
+	if b.state == state {
+		return
+	}
+
+	from := b.state
+	b.state = state
+
+	if b.onChange != nil {
+		b.onChange(from, state)
+	}
+}
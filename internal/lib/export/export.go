@@ -0,0 +1,141 @@
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/imperatorofdwelling/payment-svc/internal/domain/model"
+	"io"
+	"time"
+)
+
+type Format string
+
+const (
+	CSV   Format = "csv"
+	JSONL Format = "jsonl"
+)
+
+var ErrUnknownFormat = errors.New("unknown export format")
+
+// columns is the fixed column order of exported files. Append new columns to the end only,
+// accounting imports the files by position.
+var columns = []string{"id", "transaction_id", "transaction_type", "status", "value", "currency", "created_at", "updated_at"}
+
+// LogsWriter writes transaction logs one by one, so exports never hold the whole result in memory.
+type LogsWriter interface {
+	Write(model.Log) error
+	Flush() error
+}
+
+func ParseFormat(s string) (Format, error) {
+	switch Format(s) {
+	case CSV, JSONL:
+		return Format(s), nil
+	default:
+		return "", fmt.Errorf("%w: %s", ErrUnknownFormat, s)
+	}
+}
+
+func (f Format) ContentType() string {
+	if f == CSV {
+		return "text/csv; charset=utf-8"
+	}
+
+	return "application/x-ndjson"
+}
+
+func NewLogsWriter(w io.Writer, format Format) (LogsWriter, error) {
+	switch format {
+	case CSV:
+		return &csvWriter{w: csv.NewWriter(w)}, nil
+	case JSONL:
+		return &jsonlWriter{enc: json.NewEncoder(w)}, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownFormat, format)
+	}
+}
+
+// record renders a log in column order. Amounts have as many decimal places as their currency
+// and timestamps are UTC RFC 3339, so files do not depend on the exporting machine.
+func record(l model.Log) []string {
+	return []string{
+		l.ID.String(),
+		l.TransactionID,
+		string(l.TransactionType),
+		string(l.Status),
+		l.Amount.Decimal(),
+		string(l.Amount.Currency),
+		formatTime(l.CreatedAt),
+		formatTime(l.UpdatedAt),
+	}
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+type csvWriter struct {
+	w             *csv.Writer
+	headerWritten bool
+}
+
+func (c *csvWriter) Write(l model.Log) error {
+	if !c.headerWritten {
+		if err := c.w.Write(columns); err != nil {
+			return err
+		}
+		c.headerWritten = true
+	}
+
+	return c.w.Write(record(l))
+}
+
+func (c *csvWriter) Flush() error {
+	// an empty export still gets the header
+	if !c.headerWritten {
+		if err := c.w.Write(columns); err != nil {
+			return err
+		}
+		c.headerWritten = true
+	}
+
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// jsonlLog fixes the key order of JSON lines to the column order.
+type jsonlLog struct {
+	ID              string `json:"id"`
+	TransactionID   string `json:"transaction_id"`
+	TransactionType string `json:"transaction_type"`
+	Status          string `json:"status"`
+	Value           string `json:"value"`
+	Currency        string `json:"currency"`
+	CreatedAt       string `json:"created_at"`
+	UpdatedAt       string `json:"updated_at"`
+}
+
+type jsonlWriter struct {
+	enc *json.Encoder
+}
+
+func (j *jsonlWriter) Write(l model.Log) error {
+	r := record(l)
+
+	return j.enc.Encode(jsonlLog{
+		ID:              r[0],
+		TransactionID:   r[1],
+		TransactionType: r[2],
+		Status:          r[3],
+		Value:           r[4],
+		Currency:        r[5],
+		CreatedAt:       r[6],
+		UpdatedAt:       r[7],
+	})
+}
+
+func (j *jsonlWriter) Flush() error {
+	return nil
+}
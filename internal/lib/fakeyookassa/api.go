@@ -0,0 +1,157 @@
+package fakeyookassa
+
+import (
+	"encoding/json"
+	"fmt"
+	yoomodel "github.com/eclipsemode/go-yookassa-sdk/yookassa/model"
+	"github.com/google/uuid"
+	"github.com/imperatorofdwelling/payment-svc/internal/domain/model"
+	"github.com/imperatorofdwelling/payment-svc/internal/lib/yooapi"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Error codes of the YooKassa error object.
+const (
+	invalidRequestCode = "invalid_request"
+	notFoundCode       = "not_found"
+)
+
+// apiError is the YooKassa error object.
+type apiError struct {
+	Type        string `json:"type"`
+	ID          string `json:"id"`
+	Code        string `json:"code"`
+	Description string `json:"description"`
+}
+
+func readBody(r *http.Request, dst any) error {
+	defer r.Body.Close()
+	return json.NewDecoder(r.Body).Decode(dst)
+}
+
+// writeObject responds with the bare object, YooKassa does not wrap responses.
+func writeObject(w http.ResponseWriter, obj any) {
+	writeJSON(w, http.StatusOK, obj)
+}
+
+func writeError(w http.ResponseWriter, status int, code, description string) {
+	writeJSON(w, status, apiError{Type: "error", ID: uuid.NewString(), Code: code, Description: description})
+}
+
+func writeNotFound(w http.ResponseWriter) {
+	writeError(w, http.StatusNotFound, notFoundCode, "object is not found")
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+// readAmount parses a positive amount of the request, answering 400 when it is not one.
+func readAmount(w http.ResponseWriter, amount yoomodel.Amount) (model.Money, bool) {
+	money, err := model.MoneyFromAmount(amount)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, invalidRequestCode, err.Error())
+		return model.Money{}, false
+	}
+
+	if money.IsZero() || money.IsNegative() {
+		writeError(w, http.StatusBadRequest, invalidRequestCode, "amount must be positive")
+		return model.Money{}, false
+	}
+
+	return money, true
+}
+
+// writeList answers a list request with the page of items created in the requested range. The cursor is
+// the offset of the page in the items ordered by creation time.
+func writeList[T any](w http.ResponseWriter, r *http.Request, items []T, key func(T) (string, *time.Time)) {
+	query := r.URL.Query()
+
+	filtered := make([]T, 0, len(items))
+
+	for _, item := range items {
+		_, createdAt := key(item)
+
+		inRange, err := createdInRange(createdAt, query.Get("created_at.gte"), query.Get("created_at.lt"))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, invalidRequestCode, err.Error())
+			return
+		}
+
+		if inRange {
+			filtered = append(filtered, item)
+		}
+	}
+
+	sort.Slice(filtered, func(i, j int) bool {
+		idI, createdI := key(filtered[i])
+		idJ, createdJ := key(filtered[j])
+
+		if !createdI.Equal(*createdJ) {
+			return createdI.Before(*createdJ)
+		}
+
+		return idI < idJ
+	})
+
+	limit := 10
+	if v := query.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > yooapi.MaxListLimit {
+			writeError(w, http.StatusBadRequest, invalidRequestCode, "limit must be from 1 to 100")
+			return
+		}
+		limit = n
+	}
+
+	offset := 0
+	if v := query.Get("cursor"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			writeError(w, http.StatusBadRequest, invalidRequestCode, "invalid cursor")
+			return
+		}
+		offset = min(n, len(filtered))
+	}
+
+	end := min(offset+limit, len(filtered))
+
+	page := yooapi.List[T]{Type: "list", Items: filtered[offset:end]}
+	if end < len(filtered) {
+		page.NextCursor = strconv.Itoa(end)
+	}
+
+	writeObject(w, page)
+}
+
+func createdInRange(createdAt *time.Time, gte, lt string) (bool, error) {
+	if gte != "" {
+		from, err := time.Parse(time.RFC3339, gte)
+		if err != nil {
+			return false, fmt.Errorf("invalid created_at.gte: %w", err)
+		}
+
+		if createdAt.Before(from) {
+			return false, nil
+		}
+	}
+
+	if lt != "" {
+		to, err := time.Parse(time.RFC3339, lt)
+		if err != nil {
+			return false, fmt.Errorf("invalid created_at.lt: %w", err)
+		}
+
+		if !createdAt.Before(to) {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
@@ -0,0 +1,217 @@
+// Package fakeyookassa is an in-process stand-in for the YooKassa API, for local development, QA and tests
+// where the real sandbox can not be reached. It serves the endpoints yooapi calls: payments with capture
+// and cancel, refunds, payouts, get-by-id and lists. Objects live in memory and move through configurable
+// status progressions, sending webhooks the way YooKassa does. Latency and failures can be injected.
+//
+// The server is an http.Handler, tests run it with httptest:
+//
+//	fake := fakeyookassa.New(fakeyookassa.DefaultOptions(), log)
+//	defer fake.Close()
+//	srv := httptest.NewServer(fake)
+//
+// and point PayApi.ApiAddr at srv.URL. Credentials are not checked.
+package fakeyookassa
+
+import (
+	"fmt"
+	yoomodel "github.com/eclipsemode/go-yookassa-sdk/yookassa/model"
+	"github.com/go-chi/chi/v5"
+	"github.com/imperatorofdwelling/payment-svc/internal/lib/yooapi"
+	"go.uber.org/zap"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// StatusesMetadataKey overrides the status progression of a single payment or payout. Its value lists the
+// statuses separated by commas, e.g. "pending,canceled".
+const StatusesMetadataKey = "fake_statuses"
+
+// Options configure the fake. Every progression starts with the status returned on creation, the next
+// status is set every StatusDelay until the list ends.
+type Options struct {
+	// Latency is added to every response.
+	Latency time.Duration
+	// ErrorRate is the share of requests, from 0 to 1, answered with 500 without being processed.
+	ErrorRate float64
+	// StatusDelay is the time an object spends in each status of its progression.
+	StatusDelay time.Duration
+
+	// PaymentStatuses is the progression of payments created with capture=true.
+	PaymentStatuses []yoomodel.TransactionStatus
+	// HeldPaymentStatuses is the progression of two-stage payments, capture and cancel take them further.
+	HeldPaymentStatuses []yoomodel.TransactionStatus
+	PayoutStatuses      []yoomodel.TransactionStatus
+	RefundStatuses      []yoomodel.TransactionStatus
+
+	// WebhookURL receives notifications about status changes, nothing is sent when it is empty.
+	WebhookURL string
+	// WebhookAttempts is how many times a notification is sent until it is answered with 200.
+	WebhookAttempts int
+}
+
+func DefaultOptions() Options {
+	return Options{
+		StatusDelay:         2 * time.Second,
+		PaymentStatuses:     []yoomodel.TransactionStatus{yoomodel.Pending, yoomodel.Succeeded},
+		HeldPaymentStatuses: []yoomodel.TransactionStatus{yoomodel.Pending, yoomodel.WaitingForCapture},
+		PayoutStatuses:      []yoomodel.TransactionStatus{yoomodel.Pending, yoomodel.Succeeded},
+		RefundStatuses:      []yoomodel.TransactionStatus{yoomodel.Pending, yoomodel.Succeeded},
+		WebhookAttempts:     3,
+	}
+}
+
+// ParseStatuses reads a comma-separated progression, e.g. "pending,succeeded".
+func ParseStatuses(s string) ([]yoomodel.TransactionStatus, error) {
+	var statuses []yoomodel.TransactionStatus
+
+	for _, part := range strings.Split(s, ",") {
+		status := yoomodel.TransactionStatus(strings.TrimSpace(part))
+
+		switch status {
+		case yoomodel.Pending, yoomodel.WaitingForCapture, yoomodel.Succeeded, yoomodel.Canceled:
+			statuses = append(statuses, status)
+		default:
+			return nil, fmt.Errorf("unknown status %q", status)
+		}
+	}
+
+	return statuses, nil
+}
+
+type Server struct {
+	opts    Options
+	handler http.Handler
+	webhook http.Client
+	log     *zap.SugaredLogger
+
+	mu         sync.Mutex
+	payments   map[string]*payment
+	payouts    map[string]*payout
+	refunds    map[string]*refund
+	keys       map[string]string
+	timers     []*time.Timer
+	failNext   []int
+	closed     bool
+	done       chan struct{}
+	webhooksWg sync.WaitGroup
+}
+
+func New(opts Options, log *zap.SugaredLogger) *Server {
+	s := &Server{
+		opts:     opts,
+		webhook:  http.Client{Timeout: 10 * time.Second},
+		log:      log,
+		payments: make(map[string]*payment),
+		payouts:  make(map[string]*payout),
+		refunds:  make(map[string]*refund),
+		keys:     make(map[string]string),
+		done:     make(chan struct{}),
+	}
+
+	r := chi.NewRouter()
+	r.Use(s.injectFaults)
+
+	r.Route("/"+string(yooapi.PaymentEndpoint), func(r chi.Router) {
+		r.Post("/", s.createPayment)
+		r.Get("/", s.listPayments)
+		r.Get("/{id}", s.getPayment)
+		r.Post("/{id}/capture", s.capturePayment)
+		r.Post("/{id}/cancel", s.cancelPayment)
+	})
+
+	r.Route("/"+string(yooapi.RefundEndpoint), func(r chi.Router) {
+		r.Post("/", s.createRefund)
+		r.Get("/{id}", s.getRefund)
+	})
+
+	r.Route("/"+string(yooapi.PayoutEndpoint), func(r chi.Router) {
+		r.Post("/", s.createPayout)
+		r.Get("/", s.listPayouts)
+		r.Get("/{id}", s.getPayout)
+	})
+
+	s.handler = r
+
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.handler.ServeHTTP(w, r)
+}
+
+// FailNext answers the next requests with the given status codes, one code per request, before ErrorRate
+// is considered.
+func (s *Server) FailNext(statusCodes ...int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.failNext = append(s.failNext, statusCodes...)
+}
+
+// Close stops the status progressions and the redelivery of notifications, then waits for the
+// notifications being sent.
+func (s *Server) Close() {
+	s.mu.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.done)
+	}
+	for _, t := range s.timers {
+		t.Stop()
+	}
+	s.timers = nil
+	s.mu.Unlock()
+
+	s.webhooksWg.Wait()
+}
+
+func (s *Server) injectFaults(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.opts.Latency > 0 {
+			select {
+			case <-time.After(s.opts.Latency):
+			case <-r.Context().Done():
+				return
+			}
+		}
+
+		s.mu.Lock()
+		var status int
+		if len(s.failNext) > 0 {
+			status = s.failNext[0]
+			s.failNext = s.failNext[1:]
+		} else if s.opts.ErrorRate > 0 && rand.Float64() < s.opts.ErrorRate {
+			status = http.StatusInternalServerError
+		}
+		s.mu.Unlock()
+
+		if status != 0 {
+			writeError(w, status, "internal_server_error", "injected failure")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// replay finds the object created earlier by a request to the same path with the same idempotence key.
+// The object is returned in its current state, not the one it had when it was created.
+func (s *Server) replay(r *http.Request) (id string, found bool) {
+	key := r.Header.Get("Idempotence-Key")
+	if key == "" {
+		return "", false
+	}
+
+	id, found = s.keys[r.URL.Path+":"+key]
+	return id, found
+}
+
+func (s *Server) remember(r *http.Request, id string) {
+	key := r.Header.Get("Idempotence-Key")
+	if key != "" {
+		s.keys[r.URL.Path+":"+key] = id
+	}
+}
@@ -0,0 +1,237 @@
+package fakeyookassa
+
+import (
+	"errors"
+	yoomodel "github.com/eclipsemode/go-yookassa-sdk/yookassa/model"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/imperatorofdwelling/payment-svc/internal/domain/model"
+	"github.com/imperatorofdwelling/payment-svc/internal/lib/yooapi"
+	"io"
+	"net/http"
+	"time"
+)
+
+// captureDeadline is how long YooKassa holds the funds of a two-stage payment.
+const captureDeadline = 7 * 24 * time.Hour
+
+type payment struct {
+	yoomodel.Payment
+	next     []yoomodel.TransactionStatus
+	refunded model.Money
+}
+
+func (p *payment) remaining() *[]yoomodel.TransactionStatus {
+	return &p.next
+}
+
+func (p *payment) setStatus(status yoomodel.TransactionStatus, at time.Time) {
+	p.Status = status
+
+	switch status {
+	case yoomodel.WaitingForCapture:
+		expiresAt := at.Add(captureDeadline)
+		p.Paid = true
+		p.ExpiresAt = &expiresAt
+	case yoomodel.Succeeded:
+		p.Paid = true
+		p.Refundable = true
+		p.ExpiresAt = nil
+		p.CapturedAt = &at
+	case yoomodel.Canceled:
+		p.Paid = false
+		p.ExpiresAt = nil
+		p.CancellationDetails = &yoomodel.CancellationDetails{Party: "merchant", Reason: "canceled_by_merchant"}
+	}
+}
+
+func (p *payment) view() any {
+	return p.Payment
+}
+
+func (s *Server) createPayment(w http.ResponseWriter, r *http.Request) {
+	var req yooapi.PaymentRequest
+
+	if err := readBody(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, invalidRequestCode, err.Error())
+		return
+	}
+
+	amount, ok := readAmount(w, req.Amount)
+	if !ok {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if id, found := s.replay(r); found {
+		writeObject(w, s.payments[id].Payment)
+		return
+	}
+
+	defaults := s.opts.HeldPaymentStatuses
+	if req.Capture {
+		defaults = s.opts.PaymentStatuses
+	}
+
+	statuses, err := progression(req.Metadata, defaults)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, invalidRequestCode, err.Error())
+		return
+	}
+
+	now := time.Now().UTC()
+	normalized := amount.ToAmount()
+
+	p := &payment{
+		Payment: yoomodel.Payment{
+			ID:          uuid.NewString(),
+			Amount:      &normalized,
+			Capture:     req.Capture,
+			Description: req.Description,
+			CreatedAt:   &now,
+			Test:        true,
+			Metadata:    req.Metadata,
+		},
+		next:     statuses,
+		refunded: model.NewMoney(0, amount.Currency),
+	}
+
+	if req.PaymentMethodData != nil {
+		p.PaymentMethodData = yoomodel.PaymentMethodData{Type: req.PaymentMethodData.Type}
+	}
+
+	if req.Confirmation != nil {
+		p.Confirmation = confirmation(*req.Confirmation, p.ID)
+	}
+
+	s.payments[p.ID] = p
+	s.remember(r, p.ID)
+	s.start(yoomodel.PaymentType, p)
+
+	writeObject(w, p.Payment)
+}
+
+// confirmation answers the requested confirmation scenario with what the payer should be given.
+func confirmation(req yoomodel.Confirmation, paymentID string) yoomodel.Confirmation {
+	c := yoomodel.Confirmation{Type: req.Type, ReturnURL: req.ReturnURL, Locale: req.Locale, Enforce: req.Enforce}
+
+	switch req.Type {
+	case yoomodel.Redirect:
+		c.ConfirmationURL = "https://yoomoney.ru/checkout/payments/v2/contract?orderId=" + paymentID
+	case yoomodel.Embedded:
+		c.ConfirmationToken = "ct-" + paymentID
+	}
+
+	return c
+}
+
+func (s *Server) getPayment(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.payments[chi.URLParam(r, "id")]
+	if !ok {
+		writeNotFound(w)
+		return
+	}
+
+	writeObject(w, p.Payment)
+}
+
+type captureReq struct {
+	Amount *yoomodel.Amount `json:"amount,omitempty"`
+}
+
+// capturePayment confirms a held payment right away, optionally for a part of its amount.
+func (s *Server) capturePayment(w http.ResponseWriter, r *http.Request) {
+	var req captureReq
+
+	if err := readBody(r, &req); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, invalidRequestCode, err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.payments[chi.URLParam(r, "id")]
+	if !ok {
+		writeNotFound(w)
+		return
+	}
+
+	if _, found := s.replay(r); found {
+		writeObject(w, p.Payment)
+		return
+	}
+
+	if p.Status != yoomodel.WaitingForCapture {
+		writeError(w, http.StatusBadRequest, invalidRequestCode, "payment is not waiting for capture")
+		return
+	}
+
+	if req.Amount != nil {
+		captured, ok := readAmount(w, *req.Amount)
+		if !ok {
+			return
+		}
+
+		held, _ := model.MoneyFromAmount(*p.Amount)
+
+		cmp, err := captured.Cmp(held)
+		if err != nil || cmp > 0 {
+			writeError(w, http.StatusBadRequest, invalidRequestCode, "captured amount exceeds the payment amount")
+			return
+		}
+
+		normalized := captured.ToAmount()
+		p.Amount = &normalized
+	}
+
+	p.next = nil
+	s.remember(r, p.ID)
+	s.changeStatus(yoomodel.PaymentType, p, yoomodel.Succeeded)
+
+	writeObject(w, p.Payment)
+}
+
+func (s *Server) cancelPayment(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.payments[chi.URLParam(r, "id")]
+	if !ok {
+		writeNotFound(w)
+		return
+	}
+
+	if _, found := s.replay(r); found {
+		writeObject(w, p.Payment)
+		return
+	}
+
+	if p.Status != yoomodel.WaitingForCapture {
+		writeError(w, http.StatusBadRequest, invalidRequestCode, "payment is not waiting for capture")
+		return
+	}
+
+	p.next = nil
+	s.remember(r, p.ID)
+	s.changeStatus(yoomodel.PaymentType, p, yoomodel.Canceled)
+
+	writeObject(w, p.Payment)
+}
+
+func (s *Server) listPayments(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	items := make([]yoomodel.Payment, 0, len(s.payments))
+	for _, p := range s.payments {
+		items = append(items, p.Payment)
+	}
+
+	writeList(w, r, items, func(p yoomodel.Payment) (string, *time.Time) { return p.ID, p.CreatedAt })
+}
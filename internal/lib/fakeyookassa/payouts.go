@@ -0,0 +1,128 @@
+package fakeyookassa
+
+import (
+	yoomodel "github.com/eclipsemode/go-yookassa-sdk/yookassa/model"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/imperatorofdwelling/payment-svc/internal/lib/yooapi"
+	"net/http"
+	"time"
+)
+
+type payout struct {
+	yoomodel.Payout
+	next []yoomodel.TransactionStatus
+}
+
+func (p *payout) remaining() *[]yoomodel.TransactionStatus {
+	return &p.next
+}
+
+func (p *payout) setStatus(status yoomodel.TransactionStatus, _ time.Time) {
+	p.Status = status
+}
+
+func (p *payout) view() any {
+	return p.Payout
+}
+
+func (s *Server) createPayout(w http.ResponseWriter, r *http.Request) {
+	var req yooapi.PayoutRequest
+
+	if err := readBody(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, invalidRequestCode, err.Error())
+		return
+	}
+
+	if (req.PayoutToken == "") == (req.PayoutDestinationData == nil) {
+		writeError(w, http.StatusBadRequest, invalidRequestCode, "either payout_token or payout_destination_data must be set")
+		return
+	}
+
+	amount, ok := readAmount(w, req.Amount)
+	if !ok {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if id, found := s.replay(r); found {
+		writeObject(w, s.payouts[id].Payout)
+		return
+	}
+
+	statuses, err := progression(req.Metadata, s.opts.PayoutStatuses)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, invalidRequestCode, err.Error())
+		return
+	}
+
+	now := time.Now().UTC()
+
+	p := &payout{
+		Payout: yoomodel.Payout{
+			ID:                uuid.NewString(),
+			Amount:            amount.ToAmount(),
+			PayoutToken:       req.PayoutToken,
+			Description:       req.Description,
+			Metadata:          req.Metadata,
+			PayoutDestination: payoutDestination(req.PayoutDestinationData),
+			CreatedAt:         &now,
+			Test:              true,
+		},
+		next: statuses,
+	}
+
+	s.payouts[p.ID] = p
+	s.remember(r, p.ID)
+	s.start(yoomodel.PayoutType, p)
+
+	writeObject(w, p.Payout)
+}
+
+// payoutDestination shows the destination the way YooKassa does, with the card number masked.
+func payoutDestination(data *yooapi.PayoutDestinationData) *yoomodel.PayoutDestination {
+	if data == nil {
+		return nil
+	}
+
+	dst := &yoomodel.PayoutDestination{
+		Type:          string(data.Type),
+		BankID:        data.BankID,
+		Phone:         data.Phone,
+		AccountNumber: data.AccountNumber,
+	}
+
+	if data.Card != nil && len(data.Card.Number) >= 10 {
+		number := data.Card.Number
+		dst.Card = yoomodel.BankCardData{First6: number[:6], Last4: number[len(number)-4:], CardType: "Unknown"}
+	}
+
+	return dst
+}
+
+func (s *Server) getPayout(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.payouts[chi.URLParam(r, "id")]
+	if !ok {
+		writeNotFound(w)
+		return
+	}
+
+	writeObject(w, p.Payout)
+}
+
+func (s *Server) listPayouts(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	items := make([]yoomodel.Payout, 0, len(s.payouts))
+	for _, p := range s.payouts {
+		items = append(items, p.Payout)
+	}
+
+	writeList(w, r, items, func(p yoomodel.Payout) (string, *time.Time) { return p.ID, p.CreatedAt })
+}
@@ -0,0 +1,117 @@
+package fakeyookassa
+
+import (
+	yoomodel "github.com/eclipsemode/go-yookassa-sdk/yookassa/model"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/imperatorofdwelling/payment-svc/internal/domain/model"
+	"github.com/imperatorofdwelling/payment-svc/internal/lib/yooapi"
+	"net/http"
+	"time"
+)
+
+type refund struct {
+	yooapi.Refund
+	next []yoomodel.TransactionStatus
+}
+
+func (r *refund) remaining() *[]yoomodel.TransactionStatus {
+	return &r.next
+}
+
+func (r *refund) setStatus(status yoomodel.TransactionStatus, _ time.Time) {
+	r.Status = status
+}
+
+func (r *refund) view() any {
+	return r.Refund
+}
+
+// createRefund refunds a part or the rest of a succeeded payment.
+func (s *Server) createRefund(w http.ResponseWriter, r *http.Request) {
+	var req yooapi.Refund
+
+	if err := readBody(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, invalidRequestCode, err.Error())
+		return
+	}
+
+	amount, ok := readAmount(w, req.Amount)
+	if !ok {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if id, found := s.replay(r); found {
+		writeObject(w, s.refunds[id].Refund)
+		return
+	}
+
+	p, ok := s.payments[req.PaymentID]
+	if !ok {
+		writeError(w, http.StatusBadRequest, invalidRequestCode, "payment is not found")
+		return
+	}
+
+	if p.Status != yoomodel.Succeeded {
+		writeError(w, http.StatusBadRequest, invalidRequestCode, "payment is not succeeded")
+		return
+	}
+
+	refunded, err := p.refunded.Add(amount)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, invalidRequestCode, err.Error())
+		return
+	}
+
+	paid, _ := model.MoneyFromAmount(*p.Amount)
+
+	if cmp, _ := refunded.Cmp(paid); cmp > 0 {
+		writeError(w, http.StatusBadRequest, invalidRequestCode, "refund amount exceeds the amount left to refund")
+		return
+	}
+
+	statuses, err := progression(nil, s.opts.RefundStatuses)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, invalidRequestCode, err.Error())
+		return
+	}
+
+	now := time.Now().UTC()
+
+	rf := &refund{
+		Refund: yooapi.Refund{
+			ID:          uuid.NewString(),
+			PaymentID:   p.ID,
+			Amount:      amount.ToAmount(),
+			Description: req.Description,
+			CreatedAt:   &now,
+		},
+		next: statuses,
+	}
+
+	p.refunded = refunded
+	refundedAmount := refunded.ToAmount()
+	p.RefundedAmount = &refundedAmount
+
+	s.refunds[rf.ID] = rf
+	s.remember(r, rf.ID)
+	s.start(yoomodel.RefundType, rf)
+
+	writeObject(w, rf.Refund)
+}
+
+func (s *Server) getRefund(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rf, ok := s.refunds[chi.URLParam(r, "id")]
+	if !ok {
+		writeNotFound(w)
+		return
+	}
+
+	writeObject(w, rf.Refund)
+}
@@ -0,0 +1,141 @@
+package fakeyookassa
+
+import (
+	"bytes"
+	"encoding/json"
+	yoomodel "github.com/eclipsemode/go-yookassa-sdk/yookassa/model"
+	"github.com/imperatorofdwelling/payment-svc/internal/domain/model"
+	"net/http"
+	"slices"
+	"time"
+)
+
+// notifiedEvents are the events YooKassa sends webhooks for.
+var notifiedEvents = []model.NotificationEvent{
+	model.PaymentSucceededEvent,
+	model.PaymentWaitingForCaptureEvent,
+	model.PaymentCanceledEvent,
+	model.RefundSucceededEvent,
+	model.PayoutSucceededEvent,
+	model.PayoutCanceledEvent,
+}
+
+// object is a payment, payout or refund moving through its progression.
+type object interface {
+	// remaining returns the statuses the object is yet to go through.
+	remaining() *[]yoomodel.TransactionStatus
+	setStatus(status yoomodel.TransactionStatus, at time.Time)
+	// view returns a copy of the object as the API shows it.
+	view() any
+}
+
+type notification struct {
+	Type   string                  `json:"type"`
+	Event  model.NotificationEvent `json:"event"`
+	Object any                     `json:"object"`
+}
+
+// progression picks the statuses of a new object: the metadata override or the configured default.
+func progression(metadata map[string]string, defaults []yoomodel.TransactionStatus) ([]yoomodel.TransactionStatus, error) {
+	if value, ok := metadata[StatusesMetadataKey]; ok {
+		return ParseStatuses(value)
+	}
+
+	if len(defaults) == 0 {
+		return []yoomodel.TransactionStatus{yoomodel.Pending}, nil
+	}
+
+	return defaults, nil
+}
+
+// start sets the first status of the progression and schedules the rest. It is called with s.mu held
+// after the object is stored, so it can be fetched by the time the notification arrives.
+func (s *Server) start(txType yoomodel.TransactionType, obj object) {
+	rest := obj.remaining()
+
+	status := (*rest)[0]
+	*rest = (*rest)[1:]
+
+	s.changeStatus(txType, obj, status)
+
+	s.scheduleStep(txType, obj)
+}
+
+func (s *Server) scheduleStep(txType yoomodel.TransactionType, obj object) {
+	if s.closed || len(*obj.remaining()) == 0 {
+		return
+	}
+
+	s.timers = append(s.timers, time.AfterFunc(s.opts.StatusDelay, func() {
+		s.step(txType, obj)
+	}))
+}
+
+func (s *Server) step(txType yoomodel.TransactionType, obj object) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rest := obj.remaining()
+	if s.closed || len(*rest) == 0 {
+		return
+	}
+
+	status := (*rest)[0]
+	*rest = (*rest)[1:]
+
+	s.changeStatus(txType, obj, status)
+	s.scheduleStep(txType, obj)
+}
+
+// changeStatus moves the object to the status and notifies about it. It is called with s.mu held.
+func (s *Server) changeStatus(txType yoomodel.TransactionType, obj object, status yoomodel.TransactionStatus) {
+	obj.setStatus(status, time.Now().UTC())
+
+	event := model.NotificationEvent(string(txType) + "." + string(status))
+	if s.opts.WebhookURL == "" || s.closed || !slices.Contains(notifiedEvents, event) {
+		return
+	}
+
+	body, err := json.Marshal(notification{Type: "notification", Event: event, Object: obj.view()})
+	if err != nil {
+		s.log.Errorf("marshalling %s notification: %v", event, err)
+		return
+	}
+
+	s.webhooksWg.Add(1)
+	go s.sendNotification(event, body)
+}
+
+// sendNotification delivers the webhook, retrying with a growing pause until it is answered with 200.
+func (s *Server) sendNotification(event model.NotificationEvent, body []byte) {
+	defer s.webhooksWg.Done()
+
+	attempts := max(s.opts.WebhookAttempts, 1)
+
+	for attempt := 1; attempt <= attempts; attempt++ {
+		res, err := s.webhook.Post(s.opts.WebhookURL, "application/json", bytes.NewReader(body))
+		if err == nil {
+			res.Body.Close()
+
+			if res.StatusCode == http.StatusOK {
+				return
+			}
+
+			s.log.Warnf("%s notification attempt %d answered with %s", event, attempt, res.Status)
+		} else {
+			s.log.Warnf("%s notification attempt %d failed: %v", event, attempt, err)
+		}
+
+		if attempt == attempts {
+			break
+		}
+
+		select {
+		case <-time.After(time.Duration(attempt) * time.Second):
+		case <-s.done:
+			return
+		}
+	}
+
+	s.log.Errorf("%s notification is not delivered after %d attempts", event, attempts)
+}
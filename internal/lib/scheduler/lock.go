@@ -0,0 +1,90 @@
+package scheduler
+
+import (
+	"context"
+	"expvar"
+	"github.com/google/uuid"
+	"time"
+)
+
+const (
+	// lockTTL bounds how long a crashed replica blocks an execution. Running executions renew it.
+	lockTTL = 30 * time.Second
+	// cronMarkerTTL and jobMarkerTTL keep the lock after an execution finished, so replicas whose
+	// timers fire a bit later see the execution as done instead of running it again.
+	cronMarkerTTL = time.Hour
+	jobMarkerTTL  = 24 * time.Hour
+)
+
+// lockMetrics is published at /debug/vars. Contended counts executions skipped because another
+// replica holds the lock, lost counts locks that expired while the execution was still running.
+var lockMetrics = expvar.NewMap("scheduler_locks")
+
+// Locker is a cluster-wide lock, see redis.LockRepo.
+type Locker interface {
+	Acquire(key, token string, ttl time.Duration) (bool, error)
+	Extend(key, token string, ttl time.Duration) (bool, error)
+	Release(key, token string) error
+}
+
+// withLock runs f only if this replica wins the lock for key. The lock is renewed while f runs and
+// kept for markerTTL afterwards. The context passed to f is canceled when the lock is lost.
+// When the lock cannot be checked the execution is skipped: running twice is worse than running late.
+func (s *Scheduler) withLock(key string, markerTTL time.Duration, f func(ctx context.Context)) {
+	token := uuid.NewString()
+
+	acquired, err := s.locker.Acquire(key, token, lockTTL)
+	if err != nil {
+		lockMetrics.Add("errors", 1)
+		s.log.Errorf("failed to acquire lock %s: %v", key, err)
+		return
+	}
+
+	if !acquired {
+		lockMetrics.Add("contended", 1)
+		s.log.Debugf("lock %s is held by another replica, skipping", key)
+		return
+	}
+
+	lockMetrics.Add("acquired", 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	done := make(chan struct{})
+	go s.renewLock(key, token, done, cancel)
+
+	f(ctx)
+	close(done)
+
+	if _, err := s.locker.Extend(key, token, markerTTL); err != nil {
+		lockMetrics.Add("errors", 1)
+		s.log.Errorf("failed to keep lock %s: %v", key, err)
+	}
+}
+
+func (s *Scheduler) renewLock(key, token string, done <-chan struct{}, cancel context.CancelFunc) {
+	ticker := time.NewTicker(lockTTL / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			extended, err := s.locker.Extend(key, token, lockTTL)
+			if err != nil {
+				lockMetrics.Add("errors", 1)
+				s.log.Errorf("failed to renew lock %s: %v", key, err)
+				continue
+			}
+
+			if !extended {
+				lockMetrics.Add("lost", 1)
+				s.log.Errorf("lock %s was lost, canceling execution", key)
+				cancel()
+				return
+			}
+		}
+	}
+}
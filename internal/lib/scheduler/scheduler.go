@@ -1,39 +1,271 @@
 package scheduler
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"github.com/google/uuid"
+	"github.com/imperatorofdwelling/payment-svc/internal/domain/model"
 	"github.com/robfig/cron/v3"
 	"go.uber.org/zap"
+	"sync"
+	"time"
 )
 
+var ErrUnknownJobKind = errors.New("unknown job kind")
+
+// JobHandler executes a persisted job. The payload is the one the job was scheduled with.
+type JobHandler func(ctx context.Context, payload []byte) error
+
+// JobStore persists one-shot jobs, see postgres.JobsRepo.
+type JobStore interface {
+	InsertJob(ctx context.Context, job *model.ScheduledJob) error
+	GetJobByID(ctx context.Context, id uuid.UUID) (*model.ScheduledJob, error)
+	GetPendingJobs(ctx context.Context) ([]model.ScheduledJob, error)
+	UpdateJobStatus(ctx context.Context, id uuid.UUID, from, to model.JobStatus, lastError string) (bool, error)
+}
+
+// restoreInterval is how often pending jobs are reloaded from the store. It picks up jobs scheduled by
+// other replicas, so a job still runs when the replica that scheduled it is gone.
+const restoreInterval = "@every 1m"
+
+// Scheduler runs recurring in-memory jobs and persisted one-shot jobs. Every replica schedules every job,
+// each execution takes a lock first so that it runs on a single replica.
 type Scheduler struct {
-	cron *cron.Cron
-	log  *zap.SugaredLogger
+	cron     *cron.Cron
+	store    JobStore
+	locker   Locker
+	handlers map[string]JobHandler
+	entries  map[uuid.UUID]cron.EntryID
+	mu       sync.Mutex
+	log      *zap.SugaredLogger
 }
 
-func NewScheduler(log *zap.SugaredLogger) *Scheduler {
+func NewScheduler(store JobStore, locker Locker, log *zap.SugaredLogger) *Scheduler {
 	c := cron.New()
-	return &Scheduler{c, log}
+	return &Scheduler{
+		cron:     c,
+		store:    store,
+		locker:   locker,
+		handlers: make(map[string]JobHandler),
+		entries:  make(map[uuid.UUID]cron.EntryID),
+		log:      log,
+	}
 }
 
 func (s *Scheduler) Start() {
+	s.cron.AddFunc(restoreInterval, func() {
+		if err := s.Restore(context.Background()); err != nil {
+			s.log.Errorf("failed to restore scheduled jobs: %v", err)
+		}
+	})
+
 	s.cron.Start()
 }
 
-func (s *Scheduler) Create(pattern string, f cron.FuncJob) {
-	s.cron.AddFunc(pattern, func() {
-		fmt.Println("Scheduler works")
+// Create registers an in-memory recurring job. The name identifies the job across replicas,
+// so it must be unique. The returned id can be passed to Remove.
+func (s *Scheduler) Create(name, pattern string, f cron.FuncJob) (cron.EntryID, error) {
+	id, err := s.cron.AddFunc(pattern, func() {
+		// cron patterns have minute precision, so the minute identifies the execution
+		slot := time.Now().UTC().Truncate(time.Minute).Format(time.RFC3339)
+
+		s.withLock("cron:"+name+":"+slot, cronMarkerTTL, func(context.Context) {
+			f()
+		})
 	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to schedule job with pattern '%s': %w", pattern, err)
+	}
+
+	return id, nil
+}
+
+func (s *Scheduler) Remove(id cron.EntryID) {
+	s.cron.Remove(id)
+}
+
+// RegisterHandler sets the handler of persisted jobs of the given kind.
+// Handlers must be registered before Restore and ScheduleJob are called for that kind.
+func (s *Scheduler) RegisterHandler(kind string, h JobHandler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.handlers[kind] = h
+}
+
+// ScheduleJob persists a one-shot job and schedules it to run at runAt. A runAt in the past runs the job right away.
+func (s *Scheduler) ScheduleJob(ctx context.Context, kind string, payload any, runAt time.Time) (*model.ScheduledJob, error) {
+	const op = "lib.scheduler.ScheduleJob"
+
+	if _, ok := s.handler(kind); !ok {
+		return nil, fmt.Errorf("%s: %w: %s", op, ErrUnknownJobKind, kind)
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	job := &model.ScheduledJob{Kind: kind, Payload: data, RunAt: runAt}
+
+	err = s.store.InsertJob(ctx, job)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.schedule(*job)
+
+	return job, nil
+}
+
+// CancelJob removes a pending persisted job. It returns false when the job already ran or was canceled.
+func (s *Scheduler) CancelJob(ctx context.Context, id uuid.UUID) (bool, error) {
+	const op = "lib.scheduler.CancelJob"
+
+	canceled, err := s.store.UpdateJobStatus(ctx, id, model.JobPending, model.JobCanceled, "")
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if !canceled {
+		return false, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if entryID, ok := s.entries[id]; ok {
+		s.cron.Remove(entryID)
+		delete(s.entries, id)
+	}
+
+	return true, nil
+}
+
+// Restore schedules every pending job from the store that is not scheduled yet. It is called on startup
+// and then periodically, overdue jobs run right away.
+func (s *Scheduler) Restore(ctx context.Context) error {
+	const op = "lib.scheduler.Restore"
+
+	jobs, err := s.store.GetPendingJobs(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	var restored int
+
+	for _, job := range jobs {
+		if _, ok := s.handler(job.Kind); !ok {
+			s.log.Errorf("%s: job %s: %v: %s", op, job.ID, ErrUnknownJobKind, job.Kind)
+			continue
+		}
+
+		if s.schedule(job) {
+			restored++
+		}
+	}
+
+	if restored > 0 {
+		s.log.Infof("restored %d scheduled jobs", restored)
+	}
+
+	return nil
 }
 
 func (s *Scheduler) Stop() {
-	if err := s.cron.Stop(); err != nil {
-		panic(err)
-	} else {
-		s.log.Info("Scheduler stopped")
+	ctx := s.cron.Stop()
+	<-ctx.Done()
+
+	s.log.Info("Scheduler stopped")
+}
+
+// schedule adds the job to cron unless it is already scheduled or running. It reports whether the job was added.
+func (s *Scheduler) schedule(job model.ScheduledJob) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.entries[job.ID]; ok {
+		return false
+	}
+
+	s.entries[job.ID] = s.cron.Schedule(formatCron(job.RunAt), cron.FuncJob(func() {
+		s.run(job)
+	}))
+
+	return true
+}
+
+// run executes the job once and records the outcome. Failed jobs are not retried automatically.
+func (s *Scheduler) run(job model.ScheduledJob) {
+	const op = "lib.scheduler.run"
+
+	s.mu.Lock()
+	if id, ok := s.entries[job.ID]; ok {
+		s.cron.Remove(id)
 	}
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.entries, job.ID)
+		s.mu.Unlock()
+	}()
+
+	s.withLock("job:"+job.ID.String(), jobMarkerTTL, func(ctx context.Context) {
+		// another replica may have run the job before this one got the lock
+		current, err := s.store.GetJobByID(ctx, job.ID)
+		if err != nil {
+			s.log.Errorf("%s: job %s: %v", op, job.ID, err)
+			return
+		}
+
+		if current.Status != model.JobPending {
+			return
+		}
+
+		h, _ := s.handler(job.Kind)
+
+		status, lastError := model.JobDone, ""
+
+		if err := h(ctx, job.Payload); err != nil {
+			s.log.Errorf("%s: job %s (%s) failed: %v", op, job.ID, job.Kind, err)
+			status, lastError = model.JobFailed, err.Error()
+		}
+
+		if _, err := s.store.UpdateJobStatus(context.Background(), job.ID, model.JobPending, status, lastError); err != nil {
+			s.log.Errorf("%s: job %s: %v", op, job.ID, err)
+		}
+	})
+}
+
+func (s *Scheduler) handler(kind string) (JobHandler, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	h, ok := s.handlers[kind]
+	return h, ok
+}
+
+// onceSchedule fires once at the given time. Cron asks for the next activation once when the entry is
+// added or the scheduler starts, and once after each run, so the second call ends the schedule.
+type onceSchedule struct {
+	at    time.Time
+	fired bool
+}
+
+func (o *onceSchedule) Next(time.Time) time.Time {
+	if o.fired {
+		return time.Time{}
+	}
+
+	o.fired = true
+
+	return o.at
 }
 
-func (s *Scheduler) formatCron() {
-	// TODO create formating cron func from time.Time to Cron's like
+// formatCron converts a point in time into a one-shot cron schedule.
+func formatCron(t time.Time) cron.Schedule {
+	return &onceSchedule{at: t}
 }
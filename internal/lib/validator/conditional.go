@@ -0,0 +1,214 @@
+package v10
+
+import (
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/zap"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Conditional rules depend on another field of the same struct, named by the first word of the tag param.
+// The name is a dot-separated path of Go field names that may go through nested structs and pointers,
+// e.g. required_if_field=Amount.Currency RUB. The condition holds when the field at the path equals one
+// of the listed values, compared in their text form, so strings, numbers and booleans can be used.
+// A nil pointer on the path means the condition does not hold.
+const (
+	requiredIfFieldTag = "required_if_field"
+	forbiddenUnlessTag = "forbidden_unless"
+	oneOfIfTag         = "oneof_if"
+
+	// omitWithTag is the name the YooKassa SDK uses for forbidden_unless with a single value.
+	omitWithTag = "omit_with"
+)
+
+// conditionParam is a parsed tag param: the path of the other field and the values it is compared with.
+type conditionParam struct {
+	path   string
+	values []string
+}
+
+// parseConditionParam splits the param into the path and at least minValues values.
+func parseConditionParam(param string, minValues int) (conditionParam, bool) {
+	words := strings.Fields(param)
+	if len(words) < 1+minValues {
+		return conditionParam{}, false
+	}
+
+	return conditionParam{path: words[0], values: words[1:]}, true
+}
+
+// requiredIfFieldValidator requires the field to be set when the other field has one of the values:
+// required_if_field=Type bank_card sbp.
+func requiredIfFieldValidator(log *zap.SugaredLogger) validator.Func {
+	return func(fl validator.FieldLevel) bool {
+		param, ok := parseConditionParam(fl.Param(), 1)
+		if !ok {
+			log.Errorf("%s validation of %s: malformed param %q", fl.GetTag(), fl.StructFieldName(), fl.Param())
+			return false
+		}
+
+		matched, ok := matchCondition(fl.Parent(), param)
+		if !ok {
+			log.Errorf("%s validation of %s: %s is not a comparable field", fl.GetTag(), fl.StructFieldName(), param.path)
+			return false
+		}
+
+		return !matched || !isEmpty(fl.Field())
+	}
+}
+
+// forbiddenUnlessValidator allows the field to be set only when the other field has one of the values:
+// forbidden_unless=Type sber_loan.
+func forbiddenUnlessValidator(log *zap.SugaredLogger) validator.Func {
+	return func(fl validator.FieldLevel) bool {
+		if isEmpty(fl.Field()) {
+			return true
+		}
+
+		param, ok := parseConditionParam(fl.Param(), 1)
+		if !ok {
+			log.Errorf("%s validation of %s: malformed param %q", fl.GetTag(), fl.StructFieldName(), fl.Param())
+			return false
+		}
+
+		matched, ok := matchCondition(fl.Parent(), param)
+		if !ok {
+			log.Errorf("%s validation of %s: %s is not a comparable field", fl.GetTag(), fl.StructFieldName(), param.path)
+			return false
+		}
+
+		return matched
+	}
+}
+
+// oneOfIfValidator narrows the allowed values of the field when the other field has the given value:
+// oneof_if=Currency JPY 100 500 1000. The first value is the condition, the rest are allowed. An empty
+// field passes, required_if_field makes it mandatory.
+func oneOfIfValidator(log *zap.SugaredLogger) validator.Func {
+	return func(fl validator.FieldLevel) bool {
+		if isEmpty(fl.Field()) {
+			return true
+		}
+
+		param, ok := parseConditionParam(fl.Param(), 2)
+		if !ok {
+			log.Errorf("%s validation of %s: malformed param %q", fl.GetTag(), fl.StructFieldName(), fl.Param())
+			return false
+		}
+
+		allowed := param.values[1:]
+		param.values = param.values[:1]
+
+		matched, ok := matchCondition(fl.Parent(), param)
+		if !ok {
+			log.Errorf("%s validation of %s: %s is not a comparable field", fl.GetTag(), fl.StructFieldName(), param.path)
+			return false
+		}
+
+		if !matched {
+			return true
+		}
+
+		value, ok := scalarString(fl.Field())
+		if !ok {
+			log.Errorf("%s validation of non-scalar field %s", fl.GetTag(), fl.StructFieldName())
+			return false
+		}
+
+		return slices.Contains(allowed, value)
+	}
+}
+
+// matchCondition reports whether the field at the path equals one of the param values. It is not ok
+// when the path does not lead to a field or the field can not be compared with text.
+func matchCondition(parent reflect.Value, param conditionParam) (matched bool, ok bool) {
+	field, found, isNil := lookupPath(parent, param.path)
+	if !found {
+		return false, false
+	}
+
+	if isNil {
+		return false, true
+	}
+
+	value, ok := scalarString(field)
+	if !ok {
+		return false, false
+	}
+
+	return slices.Contains(param.values, value), true
+}
+
+// lookupPath walks the dot-separated field names from the struct. isNil is set when a pointer on the
+// way or the field itself is nil, the fields after it can not be reached but the path may be correct.
+func lookupPath(v reflect.Value, path string) (field reflect.Value, found bool, isNil bool) {
+	for _, name := range strings.Split(path, ".") {
+		if v, isNil = indirect(v); isNil {
+			return reflect.Value{}, true, true
+		}
+
+		if v.Kind() != reflect.Struct {
+			return reflect.Value{}, false, false
+		}
+
+		v = v.FieldByName(name)
+		if !v.IsValid() {
+			return reflect.Value{}, false, false
+		}
+	}
+
+	if v, isNil = indirect(v); isNil {
+		return reflect.Value{}, true, true
+	}
+
+	return v, true, false
+}
+
+// indirect follows pointers and interfaces down to the value they hold.
+func indirect(v reflect.Value) (reflect.Value, bool) {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return reflect.Value{}, true
+		}
+		v = v.Elem()
+	}
+
+	return v, false
+}
+
+// scalarString formats strings, numbers and booleans the way they are written in tag params.
+func scalarString(v reflect.Value) (string, bool) {
+	v, isNil := indirect(v)
+	if isNil {
+		return "", false
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(v.Uint(), 10), true
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, v.Type().Bits()), true
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), true
+	default:
+		return "", false
+	}
+}
+
+// isEmpty treats nil pointers, zero values and empty collections as a field that is not set.
+func isEmpty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Invalid:
+		return true
+	case reflect.Slice, reflect.Map:
+		return v.Len() == 0
+	default:
+		return v.IsZero()
+	}
+}
@@ -0,0 +1,266 @@
+package v10
+
+import (
+	"github.com/go-playground/validator/v10"
+	"github.com/imperatorofdwelling/payment-svc/internal/config"
+	"go.uber.org/zap"
+	"testing"
+)
+
+type conditionAmount struct {
+	Currency string
+	Scale    int
+	Exact    bool
+	Rate     float64
+	Units    uint
+	Tags     []string
+}
+
+type conditionHolder struct {
+	Amount *conditionAmount
+}
+
+type requiredIfFieldCase struct {
+	Type   string
+	Count  int
+	Flag   bool
+	Rate   float64
+	Units  uint
+	Amount *conditionAmount
+	Holder *conditionHolder
+	Tags   []string
+	Any    any
+	Kind   *string
+
+	ByType     string            `validate:"required_if_field=Type card"`
+	ByTypes    string            `validate:"required_if_field=Type sbp yoo_money"`
+	ByCount    string            `validate:"required_if_field=Count 2"`
+	ByFlag     string            `validate:"required_if_field=Flag true"`
+	ByRate     string            `validate:"required_if_field=Rate 1.5"`
+	ByUnits    string            `validate:"required_if_field=Units 7"`
+	ByNested   string            `validate:"required_if_field=Amount.Currency JPY"`
+	ByDeep     string            `validate:"required_if_field=Holder.Amount.Scale 0"`
+	ByAny      string            `validate:"required_if_field=Any card"`
+	ByKind     string            `validate:"required_if_field=Kind card"`
+	Pointer    *string           `validate:"required_if_field=Type pointer"`
+	Slice      []string          `validate:"required_if_field=Type slice"`
+	Map        map[string]string `validate:"required_if_field=Type map"`
+	NotScalar  string            `validate:"required_if_field=Tags card"`
+	NotField   string            `validate:"required_if_field=Missing card"`
+	NotStruct  string            `validate:"required_if_field=Type.Inner card"`
+	NoValues   string            `validate:"required_if_field=Type"`
+	EmptyParam string            `validate:"required_if_field="`
+}
+
+type forbiddenUnlessCase struct {
+	Type   string
+	Count  int
+	Amount *conditionAmount
+
+	ByType    string   `validate:"forbidden_unless=Type card"`
+	ByTypes   string   `validate:"forbidden_unless=Type sbp yoo_money"`
+	ByCount   int      `validate:"forbidden_unless=Count 3"`
+	ByNested  string   `validate:"forbidden_unless=Amount.Currency RUB"`
+	Pointer   *string  `validate:"forbidden_unless=Type pointer"`
+	Slice     []string `validate:"forbidden_unless=Type slice"`
+	Legacy    string   `validate:"omit_with=Type card"`
+	NotField  string   `validate:"forbidden_unless=Missing card"`
+	NotScalar string   `validate:"forbidden_unless=Amount.Tags card"`
+	NoValues  string   `validate:"forbidden_unless=Type"`
+}
+
+type oneOfIfCase struct {
+	Currency string
+	Count    int
+	Amount   *conditionAmount
+
+	Value     string   `validate:"oneof_if=Currency JPY 100 500"`
+	Number    int      `validate:"oneof_if=Count 2 10 20"`
+	Pointer   *int     `validate:"oneof_if=Currency JPY 1"`
+	ByNested  string   `validate:"oneof_if=Amount.Currency KWD 0.001"`
+	NotScalar []string `validate:"oneof_if=Currency JPY 100"`
+	NotField  string   `validate:"oneof_if=Missing JPY 100"`
+	OneValue  string   `validate:"oneof_if=Currency JPY"`
+	NoValues  string   `validate:"oneof_if=Currency"`
+}
+
+func newTestValidator(t *testing.T) {
+	t.Helper()
+
+	NewValidator(config.Currencies{Payments: []string{"RUB"}, Payouts: []string{"RUB"}}, zap.NewNop().Sugar())
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func TestRequiredIfField(t *testing.T) {
+	newTestValidator(t)
+
+	tests := []struct {
+		name  string
+		value requiredIfFieldCase
+		field string
+		valid bool
+	}{
+		{name: "condition does not hold", value: requiredIfFieldCase{Type: "bank_card"}, valid: true},
+		{name: "string condition holds, field set", value: requiredIfFieldCase{Type: "card", ByType: "x"}, valid: true},
+		{name: "string condition holds, field empty", value: requiredIfFieldCase{Type: "card"}, field: "ByType"},
+		{name: "first of values holds", value: requiredIfFieldCase{Type: "sbp", ByTypes: "x"}, valid: true},
+		{name: "second of values holds", value: requiredIfFieldCase{Type: "yoo_money"}, field: "ByTypes"},
+		{name: "int condition holds", value: requiredIfFieldCase{Count: 2}, field: "ByCount"},
+		{name: "int condition does not hold", value: requiredIfFieldCase{Count: 3}, valid: true},
+		{name: "bool condition holds", value: requiredIfFieldCase{Flag: true}, field: "ByFlag"},
+		{name: "bool condition does not hold", value: requiredIfFieldCase{Flag: false}, valid: true},
+		{name: "float condition holds", value: requiredIfFieldCase{Rate: 1.5}, field: "ByRate"},
+		{name: "uint condition holds", value: requiredIfFieldCase{Units: 7}, field: "ByUnits"},
+		{name: "nested condition holds", value: requiredIfFieldCase{Amount: &conditionAmount{Currency: "JPY"}}, field: "ByNested"},
+		{name: "nested condition holds, field set", value: requiredIfFieldCase{Amount: &conditionAmount{Currency: "JPY"}, ByNested: "x"}, valid: true},
+		{name: "nested condition does not hold", value: requiredIfFieldCase{Amount: &conditionAmount{Currency: "RUB"}}, valid: true},
+		{name: "nil pointer on path", value: requiredIfFieldCase{}, valid: true},
+		{name: "nil interface condition", value: requiredIfFieldCase{ByAny: "x"}, valid: true},
+		{name: "nil pointer condition", value: requiredIfFieldCase{ByKind: "x"}, valid: true},
+		{name: "nil pointer deep on path", value: requiredIfFieldCase{Holder: &conditionHolder{}}, valid: true},
+		{name: "deep condition holds", value: requiredIfFieldCase{Holder: &conditionHolder{Amount: &conditionAmount{}}}, field: "ByDeep"},
+		{name: "interface condition holds", value: requiredIfFieldCase{Any: "card"}, field: "ByAny"},
+		{name: "interface condition of other kind", value: requiredIfFieldCase{Any: 1}, valid: true},
+		{name: "pointer condition holds", value: requiredIfFieldCase{Kind: ptr("card")}, field: "ByKind"},
+		{name: "pointer condition does not hold", value: requiredIfFieldCase{Kind: ptr("sbp")}, valid: true},
+		{name: "pointer field empty", value: requiredIfFieldCase{Type: "pointer"}, field: "Pointer"},
+		{name: "pointer to empty value", value: requiredIfFieldCase{Type: "pointer", Pointer: ptr("")}, field: "Pointer"},
+		{name: "pointer field set", value: requiredIfFieldCase{Type: "pointer", Pointer: ptr("x")}, valid: true},
+		{name: "slice field empty", value: requiredIfFieldCase{Type: "slice", Slice: []string{}}, field: "Slice"},
+		{name: "slice field set", value: requiredIfFieldCase{Type: "slice", Slice: []string{"x"}}, valid: true},
+		{name: "map field empty", value: requiredIfFieldCase{Type: "map"}, field: "Map"},
+		{name: "map field set", value: requiredIfFieldCase{Type: "map", Map: map[string]string{"k": "v"}}, valid: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertFieldErrors(t, tt.value, tt.field, tt.valid, "NotScalar", "NotField", "NotStruct", "NoValues", "EmptyParam")
+		})
+	}
+}
+
+func TestForbiddenUnless(t *testing.T) {
+	newTestValidator(t)
+
+	tests := []struct {
+		name  string
+		value forbiddenUnlessCase
+		field string
+		valid bool
+	}{
+		{name: "empty fields pass", value: forbiddenUnlessCase{}, valid: true},
+		{name: "set when condition holds", value: forbiddenUnlessCase{Type: "card", ByType: "x"}, valid: true},
+		{name: "set when condition does not hold", value: forbiddenUnlessCase{Type: "sbp", ByType: "x"}, field: "ByType"},
+		{name: "set when second of values holds", value: forbiddenUnlessCase{Type: "yoo_money", ByTypes: "x"}, valid: true},
+		{name: "set when none of values holds", value: forbiddenUnlessCase{Type: "card", ByTypes: "x"}, field: "ByTypes"},
+		{name: "int field set when condition holds", value: forbiddenUnlessCase{Count: 3, ByCount: 1}, valid: true},
+		{name: "int field set when condition does not hold", value: forbiddenUnlessCase{Count: 4, ByCount: 1}, field: "ByCount"},
+		{name: "nested condition holds", value: forbiddenUnlessCase{Amount: &conditionAmount{Currency: "RUB"}, ByNested: "x"}, valid: true},
+		{name: "nested condition does not hold", value: forbiddenUnlessCase{Amount: &conditionAmount{Currency: "JPY"}, ByNested: "x"}, field: "ByNested"},
+		{name: "nil pointer on path", value: forbiddenUnlessCase{ByNested: "x"}, field: "ByNested"},
+		{name: "pointer to empty value passes", value: forbiddenUnlessCase{Pointer: ptr("")}, valid: true},
+		{name: "pointer field set", value: forbiddenUnlessCase{Pointer: ptr("x")}, field: "Pointer"},
+		{name: "pointer field set when condition holds", value: forbiddenUnlessCase{Type: "pointer", Pointer: ptr("x")}, valid: true},
+		{name: "empty slice passes", value: forbiddenUnlessCase{Slice: []string{}}, valid: true},
+		{name: "slice field set", value: forbiddenUnlessCase{Slice: []string{"x"}}, field: "Slice"},
+		{name: "omit_with when condition holds", value: forbiddenUnlessCase{Type: "card", ByType: "x", Legacy: "x"}, valid: true},
+		{name: "omit_with when condition does not hold", value: forbiddenUnlessCase{Legacy: "x"}, field: "Legacy"},
+		{name: "unknown path", value: forbiddenUnlessCase{NotField: "x"}, field: "NotField"},
+		{name: "non-scalar condition", value: forbiddenUnlessCase{Amount: &conditionAmount{}, NotScalar: "x"}, field: "NotScalar"},
+		{name: "malformed param", value: forbiddenUnlessCase{NoValues: "x"}, field: "NoValues"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertFieldErrors(t, tt.value, tt.field, tt.valid)
+		})
+	}
+}
+
+func TestOneOfIf(t *testing.T) {
+	newTestValidator(t)
+
+	tests := []struct {
+		name  string
+		value oneOfIfCase
+		field string
+		valid bool
+	}{
+		{name: "empty fields pass", value: oneOfIfCase{Currency: "JPY"}, valid: true},
+		{name: "condition does not hold", value: oneOfIfCase{Currency: "RUB", Value: "7"}, valid: true},
+		{name: "allowed value", value: oneOfIfCase{Currency: "JPY", Value: "500"}, valid: true},
+		{name: "other allowed value", value: oneOfIfCase{Currency: "JPY", Value: "100"}, valid: true},
+		{name: "value not allowed", value: oneOfIfCase{Currency: "JPY", Value: "7"}, field: "Value"},
+		{name: "condition value is not allowed", value: oneOfIfCase{Currency: "JPY", Value: "JPY"}, field: "Value"},
+		{name: "int field allowed", value: oneOfIfCase{Count: 2, Number: 20}, valid: true},
+		{name: "int field not allowed", value: oneOfIfCase{Count: 2, Number: 15}, field: "Number"},
+		{name: "int condition does not hold", value: oneOfIfCase{Count: 3, Number: 15}, valid: true},
+		{name: "pointer field allowed", value: oneOfIfCase{Currency: "JPY", Pointer: ptr(1)}, valid: true},
+		{name: "pointer field not allowed", value: oneOfIfCase{Currency: "JPY", Pointer: ptr(2)}, field: "Pointer"},
+		{name: "nested condition holds", value: oneOfIfCase{Amount: &conditionAmount{Currency: "KWD"}, ByNested: "0.01"}, field: "ByNested"},
+		{name: "nested value allowed", value: oneOfIfCase{Amount: &conditionAmount{Currency: "KWD"}, ByNested: "0.001"}, valid: true},
+		{name: "nil pointer on path", value: oneOfIfCase{ByNested: "0.01"}, valid: true},
+		{name: "non-scalar field", value: oneOfIfCase{Currency: "JPY", NotScalar: []string{"100"}}, field: "NotScalar"},
+		{name: "non-scalar field when condition does not hold", value: oneOfIfCase{NotScalar: []string{"100"}}, valid: true},
+		{name: "unknown path", value: oneOfIfCase{NotField: "100"}, field: "NotField"},
+		{name: "condition without allowed values", value: oneOfIfCase{Currency: "JPY", OneValue: "100"}, field: "OneValue"},
+		{name: "malformed param", value: oneOfIfCase{NoValues: "100"}, field: "NoValues"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertFieldErrors(t, tt.value, tt.field, tt.valid)
+		})
+	}
+}
+
+// assertFieldErrors validates the value and expects either no errors or a single error on field. Fields whose
+// rules can never pass are listed in broken, their errors are expected on every value and are not counted.
+func assertFieldErrors(t *testing.T, value any, field string, valid bool, broken ...string) {
+	t.Helper()
+
+	failed := validationFailures(t, value)
+
+	for _, name := range broken {
+		if !failed[name] {
+			t.Errorf("expected %s with a broken rule to fail", name)
+		}
+		delete(failed, name)
+	}
+
+	if valid {
+		if len(failed) > 0 {
+			t.Errorf("expected no errors, got %v", failed)
+		}
+		return
+	}
+
+	if !failed[field] || len(failed) != 1 {
+		t.Errorf("expected a single error on %s, got %v", field, failed)
+	}
+}
+
+func validationFailures(t *testing.T, value any) map[string]bool {
+	t.Helper()
+
+	failed := make(map[string]bool)
+
+	err := Validate.Struct(value)
+	if err == nil {
+		return failed
+	}
+
+	errs, ok := err.(validator.ValidationErrors)
+	if !ok {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, fe := range errs {
+		failed[fe.StructField()] = true
+	}
+
+	return failed
+}
@@ -0,0 +1,83 @@
+package v10
+
+import (
+	yoomodel "github.com/eclipsemode/go-yookassa-sdk/yookassa/model"
+	"github.com/go-playground/validator/v10"
+	"github.com/imperatorofdwelling/payment-svc/internal/config"
+	"github.com/imperatorofdwelling/payment-svc/internal/domain/model"
+	"go.uber.org/zap"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// Operations accepted as the supported_currency tag param.
+const (
+	PaymentsOperation = "payments"
+	PayoutsOperation  = "payouts"
+)
+
+// supportedCurrencies keeps the configured currencies per operation.
+type supportedCurrencies map[string][]yoomodel.Currency
+
+func newSupportedCurrencies(cfg config.Currencies) supportedCurrencies {
+	return supportedCurrencies{
+		PaymentsOperation: toCurrencies(cfg.Payments),
+		PayoutsOperation:  toCurrencies(cfg.Payouts),
+	}
+}
+
+func toCurrencies(codes []string) []yoomodel.Currency {
+	currencies := make([]yoomodel.Currency, 0, len(codes))
+
+	for _, code := range codes {
+		currencies = append(currencies, yoomodel.Currency(strings.ToUpper(strings.TrimSpace(code))))
+	}
+
+	return currencies
+}
+
+// supports reports whether the currency is enabled for the operation. Unknown operations support nothing.
+func (s supportedCurrencies) supports(operation string, currency yoomodel.Currency) bool {
+	return slices.Contains(s[operation], currency)
+}
+
+// validator checks the field against the operation named by the tag param, e.g. supported_currency=payouts.
+func (s supportedCurrencies) validator(log *zap.SugaredLogger) validator.Func {
+	return func(fl validator.FieldLevel) bool {
+		v := fl.Field()
+
+		if v.Kind() != reflect.String {
+			log.Errorf("supported_currency validation of non-string field %s", fl.StructFieldName())
+			return false
+		}
+
+		return s.supports(fl.Param(), yoomodel.Currency(v.String()))
+	}
+}
+
+// paymentValidation applies the payments currency list to payment request bodies. The amount type is shared
+// with payouts, so the check can not be a field tag.
+func (s supportedCurrencies) paymentValidation(sl validator.StructLevel) {
+	payment := sl.Current().Interface().(model.PaymentRequest)
+
+	if payment.Amount.Currency == "" {
+		return
+	}
+
+	if !s.supports(PaymentsOperation, payment.Amount.Currency) {
+		sl.ReportError(payment.Amount.Currency, "amount.currency", "Amount.Currency", "supported_currency", PaymentsOperation)
+	}
+}
+
+func (s supportedCurrencies) payoutValidation(sl validator.StructLevel) {
+	payout := sl.Current().Interface().(model.PayoutRequest)
+
+	if payout.Amount.Currency == "" {
+		return
+	}
+
+	if !s.supports(PayoutsOperation, payout.Amount.Currency) {
+		sl.ReportError(payout.Amount.Currency, "amount.currency", "Amount.Currency", "supported_currency", PayoutsOperation)
+	}
+}
@@ -0,0 +1,231 @@
+package v10
+
+import (
+	"errors"
+	"fmt"
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/ru"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	entranslations "github.com/go-playground/validator/v10/translations/en"
+	rutranslations "github.com/go-playground/validator/v10/translations/ru"
+	"github.com/imperatorofdwelling/payment-svc/pkg/json"
+	"strings"
+)
+
+// invalidKey is the message of rules that have no translation of their own.
+const invalidKey = "invalid"
+
+var translator *ut.UniversalTranslator
+
+// customTranslation holds the messages of a rule in every supported language. Params are passed
+// to the messages as {1}, {2}..., {0} is always the field name.
+type customTranslation struct {
+	tag      string
+	messages map[string]string
+	params   func(fe validator.FieldError, locale string) []string
+}
+
+var operationNames = map[string]map[string]string{
+	"en": {PaymentsOperation: "payments", PayoutsOperation: "payouts"},
+	"ru": {PaymentsOperation: "платежей", PayoutsOperation: "выплат"},
+}
+
+var orWords = map[string]string{"en": " or ", "ru": " или "}
+
+// conditionParams names the other field of a conditional rule and the values it is compared with.
+func conditionParams(fe validator.FieldError, locale string) []string {
+	param, _ := parseConditionParam(fe.Param(), 1)
+	return []string{param.path, strings.Join(param.values, orWords[locale])}
+}
+
+var customTranslations = []customTranslation{
+	{
+		tag: "money",
+		messages: map[string]string{
+			"en": "{0} must be a non-negative amount with no more decimal places than its currency has",
+			"ru": "{0} должно быть неотрицательной суммой, знаков после запятой не больше, чем у валюты",
+		},
+	},
+	{
+		tag: "supported_currency",
+		messages: map[string]string{
+			"en": "{0}: currency {1} is not supported for {2}",
+			"ru": "{0}: валюта {1} не поддерживается для {2}",
+		},
+		params: func(fe validator.FieldError, locale string) []string {
+			operation := operationNames[locale][fe.Param()]
+			if operation == "" {
+				operation = fe.Param()
+			}
+			return []string{fmt.Sprint(fe.Value()), operation}
+		},
+	},
+	{
+		tag: "iso4217",
+		messages: map[string]string{
+			"en": "{0} must be an ISO 4217 currency code",
+			"ru": "{0} должно быть кодом валюты ISO 4217",
+		},
+	},
+	{
+		tag: "required_if",
+		messages: map[string]string{
+			"en": "{0} is a required field",
+			"ru": "{0} обязательное поле",
+		},
+	},
+	{
+		tag: requiredIfFieldTag,
+		messages: map[string]string{
+			"en": "{0} is required when {1} is {2}",
+			"ru": "{0} обязательное поле, если {1} равно {2}",
+		},
+		params: conditionParams,
+	},
+	{
+		tag: forbiddenUnlessTag,
+		messages: map[string]string{
+			"en": "{0} can only be set when {1} is {2}",
+			"ru": "{0} можно указать, только если {1} равно {2}",
+		},
+		params: conditionParams,
+	},
+	{
+		tag: omitWithTag,
+		messages: map[string]string{
+			"en": "{0} can only be set when {1} is {2}",
+			"ru": "{0} можно указать, только если {1} равно {2}",
+		},
+		params: conditionParams,
+	},
+	{
+		tag: oneOfIfTag,
+		messages: map[string]string{
+			"en": "{0} must be one of [{1}] when {2} is {3}",
+			"ru": "{0} должно быть одним из [{1}], если {2} равно {3}",
+		},
+		params: func(fe validator.FieldError, _ string) []string {
+			param, _ := parseConditionParam(fe.Param(), 2)
+			if len(param.values) < 2 {
+				return []string{"", param.path, ""}
+			}
+			return []string{strings.Join(param.values[1:], " "), param.path, param.values[0]}
+		},
+	},
+	{
+		tag: invalidKey,
+		messages: map[string]string{
+			"en": "{0} is invalid",
+			"ru": "{0} имеет недопустимое значение",
+		},
+	},
+}
+
+func registerTranslations(validate *validator.Validate) error {
+	enLocale := en.New()
+	translator = ut.New(enLocale, enLocale, ru.New())
+
+	enTrans, _ := translator.GetTranslator("en")
+	ruTrans, _ := translator.GetTranslator("ru")
+
+	if err := entranslations.RegisterDefaultTranslations(validate, enTrans); err != nil {
+		return err
+	}
+
+	if err := rutranslations.RegisterDefaultTranslations(validate, ruTrans); err != nil {
+		return err
+	}
+
+	for _, trans := range []ut.Translator{enTrans, ruTrans} {
+		for _, ct := range customTranslations {
+			err := validate.RegisterTranslation(ct.tag, trans, registerMessage(ct), translateMessage(ct))
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func registerMessage(ct customTranslation) validator.RegisterTranslationsFunc {
+	return func(trans ut.Translator) error {
+		return trans.Add(ct.tag, ct.messages[trans.Locale()], true)
+	}
+}
+
+func translateMessage(ct customTranslation) validator.TranslationFunc {
+	return func(trans ut.Translator, fe validator.FieldError) string {
+		params := []string{fe.Field()}
+		if ct.params != nil {
+			params = append(params, ct.params(fe, trans.Locale())...)
+		}
+
+		msg, err := trans.T(ct.tag, params...)
+		if err != nil {
+			return fe.Error()
+		}
+
+		return msg
+	}
+}
+
+// FieldErrors describes validation errors field by field in the first language of the Accept-Language
+// header value that is supported, English otherwise. Other errors produce no fields.
+func FieldErrors(err error, acceptLanguage string) []json.FieldError {
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		return nil
+	}
+
+	trans := findTranslator(acceptLanguage)
+
+	fields := make([]json.FieldError, 0, len(validationErrs))
+
+	for _, fe := range validationErrs {
+		msg := fe.Translate(trans)
+		if msg == fe.Error() {
+			msg, _ = trans.T(invalidKey, fe.Field())
+		}
+
+		fields = append(fields, json.FieldError{
+			Field:   fieldPath(fe.Namespace()),
+			Tag:     fe.Tag(),
+			Param:   fe.Param(),
+			Message: msg,
+		})
+	}
+
+	return fields
+}
+
+// fieldPath drops the root struct name and the segments of embedded structs from the namespace.
+func fieldPath(namespace string) string {
+	segments := strings.Split(namespace, ".")
+
+	path := make([]string, 0, len(segments))
+	for _, s := range segments[1:] {
+		if s != "" {
+			path = append(path, s)
+		}
+	}
+
+	return strings.Join(path, ".")
+}
+
+func findTranslator(acceptLanguage string) ut.Translator {
+	var locales []string
+
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, _, _ := strings.Cut(strings.TrimSpace(part), ";")
+		lang, _, _ := strings.Cut(tag, "-")
+		if lang != "" {
+			locales = append(locales, strings.ToLower(lang))
+		}
+	}
+
+	trans, _ := translator.FindTranslator(locales...)
+
+	return trans
+}
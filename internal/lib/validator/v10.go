@@ -1,7 +1,10 @@
 package v10
 
 import (
+	yoomodel "github.com/eclipsemode/go-yookassa-sdk/yookassa/model"
 	"github.com/go-playground/validator/v10"
+	"github.com/imperatorofdwelling/payment-svc/internal/config"
+	"github.com/imperatorofdwelling/payment-svc/internal/domain/model"
 	"go.uber.org/zap"
 	"reflect"
 	"regexp"
@@ -10,75 +13,104 @@ import (
 
 var Validate *validator.Validate
 
-func NewValidator(log *zap.SugaredLogger) {
+var anyMoneyPattern = regexp.MustCompile(`^\d+(\.\d{1,3})?$`)
+
+func NewValidator(currencies config.Currencies, log *zap.SugaredLogger) {
 	newValidate := validator.New(validator.WithRequiredStructEnabled())
 
+	newValidate.RegisterTagNameFunc(jsonFieldName)
+
+	supported := newSupportedCurrencies(currencies)
+
 	registerValidation("money", moneyValidator, log, newValidate)
-	registerValidation("omit_with", omitOptionValidator, log, newValidate)
+	registerValidation("supported_currency", supported.validator, log, newValidate)
+
+	// Conditional rules also run on nil pointers: a missing field is what they are about.
+	registerValidation(requiredIfFieldTag, requiredIfFieldValidator, log, newValidate, true)
+	registerValidation(forbiddenUnlessTag, forbiddenUnlessValidator, log, newValidate, true)
+	registerValidation(omitWithTag, forbiddenUnlessValidator, log, newValidate, true)
+	registerValidation(oneOfIfTag, oneOfIfValidator, log, newValidate, true)
+
+	newValidate.RegisterStructValidation(supported.paymentValidation, model.PaymentRequest{})
+	newValidate.RegisterStructValidation(supported.payoutValidation, model.PayoutRequest{})
+
+	err := registerTranslations(newValidate)
+	if err != nil {
+		log.Errorf("failed to register validation translations: %s", err.Error())
+	}
 
 	Validate = newValidate
 }
 
-func registerValidation(tag string, fn func(*zap.SugaredLogger) validator.Func, log *zap.SugaredLogger, validate *validator.Validate) {
-	err := validate.RegisterValidation(tag, fn(log))
+// embeddedFieldName names embedded structs without a json tag. Their fields are flattened into the parent
+// in JSON, and the empty path segments this name produces are dropped from field paths.
+const embeddedFieldName = "."
+
+// jsonFieldName names fields by their json tag, so validation errors point at request body keys.
+func jsonFieldName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+
+	switch {
+	case name == "-":
+		return field.Name
+	case name != "":
+		return name
+	case field.Anonymous:
+		return embeddedFieldName
+	default:
+		return field.Name
+	}
+}
+
+func registerValidation(tag string, fn func(*zap.SugaredLogger) validator.Func, log *zap.SugaredLogger, validate *validator.Validate, callEvenIfNull ...bool) {
+	err := validate.RegisterValidation(tag, fn(log), callEvenIfNull...)
 	if err != nil {
 		log.Errorf("failed to register validation '%s': %s", tag, err.Error())
 	}
 }
 
+// moneyValidator accepts a non-negative decimal amount. The decimal places are checked against the currency
+// in the sibling field, Currency unless the tag names another one (money=Code): none for JPY, two for RUB.
+// Without a currency up to three decimal places are accepted, the most any ISO 4217 currency has.
 func moneyValidator(log *zap.SugaredLogger) validator.Func {
 	return func(fl validator.FieldLevel) bool {
 		v := fl.Field()
 
 		if v.Kind() != reflect.String {
-			log.Errorf("value %v is not a string", v)
+			log.Errorf("money validation of non-string field %s", fl.StructFieldName())
 			return false
 		}
 
-		pattern := `^\d+(\.\d{2})?$`
-
-		reg := regexp.MustCompile(pattern)
-
-		if !reg.MatchString(v.String()) {
-			log.Errorf("value %v is not a valid currency", v)
-			return false
+		currencyField := fl.Param()
+		if currencyField == "" {
+			currencyField = "Currency"
 		}
 
-		return true
-	}
-}
-
-// omitOptionValidator describes field is no required, but in can only used with chosen field values in struct.
-func omitOptionValidator(log *zap.SugaredLogger) validator.Func {
-	return func(fl validator.FieldLevel) bool {
-		v := fl.Field()
-
-		if v.Kind() != reflect.String {
-			log.Errorf("value %v is not a string", v)
-			return false
+		currency := siblingCurrency(fl.Parent(), currencyField)
+		if currency == "" {
+			return anyMoneyPattern.MatchString(v.String())
 		}
 
-		param := fl.Param()
-
-		arrParam := strings.Split(param, " ")
-		key := arrParam[0]
-		value := arrParam[1]
-
-		parent := fl.Parent()
-
-		if parent.Kind() != reflect.Struct {
-			log.Errorf("value %v is not a struct", v)
+		if strings.HasPrefix(v.String(), "-") {
 			return false
 		}
 
-		if v.IsZero() && v.String() != parent.FieldByName(key).String() {
-			return true
-		}
+		_, err := model.ParseMoney(v.String(), currency)
+		return err == nil
+	}
+}
 
-		if parent.FieldByName(key).String() != value {
-			return false
-		}
+// siblingCurrency returns the value of the named string field of the struct, empty when there is none.
+func siblingCurrency(parent reflect.Value, name string) yoomodel.Currency {
+	parent = reflect.Indirect(parent)
+	if parent.Kind() != reflect.Struct {
+		return ""
+	}
 
-		return true
+	field := parent.FieldByName(name)
+	if !field.IsValid() || field.Kind() != reflect.String {
+		return ""
 	}
+
+	return yoomodel.Currency(field.String())
 }
@@ -0,0 +1,41 @@
+package yooapi
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// MaxListLimit is the largest page YooKassa returns for list requests.
+const MaxListLimit = 100
+
+// List is a page of YooKassa objects. NextCursor is empty on the last page.
+type List[T any] struct {
+	Type       string `json:"type"`
+	Items      []T    `json:"items"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// ListQuery builds the query of a list request for objects created in [from, to).
+func ListQuery(from, to time.Time, cursor string) url.Values {
+	query := url.Values{}
+	query.Set("created_at.gte", from.UTC().Format(time.RFC3339))
+	query.Set("created_at.lt", to.UTC().Format(time.RFC3339))
+	query.Set("limit", strconv.Itoa(MaxListLimit))
+
+	if cursor != "" {
+		query.Set("cursor", cursor)
+	}
+
+	return query
+}
+
+func (c *Client) ListPayments(ctx context.Context, query url.Values) (*http.Response, error) {
+	return c.makeRequest(ctx, http.MethodGet, PaymentEndpoint, "", nil, query, "")
+}
+
+func (c *Client) ListPayouts(ctx context.Context, query url.Values) (*http.Response, error) {
+	return c.makeRequest(ctx, http.MethodGet, PayoutEndpoint, "", nil, query, "")
+}
@@ -0,0 +1,38 @@
+package yooapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	yoomodel "github.com/eclipsemode/go-yookassa-sdk/yookassa/model"
+	"net/http"
+)
+
+// PaymentRequest is the body of a new payment. The SDK payment model sends the method data as
+// payment_method and has no payment_token or payment_method_id, so it is only used for responses.
+type PaymentRequest struct {
+	Amount            yoomodel.Amount        `json:"amount"`
+	Description       string                 `json:"description,omitempty"`
+	Capture           bool                   `json:"capture"`
+	Confirmation      *yoomodel.Confirmation `json:"confirmation,omitempty"`
+	PaymentMethodData *PaymentMethodData     `json:"payment_method_data,omitempty"`
+	PaymentMethodID   string                 `json:"payment_method_id,omitempty"`
+	PaymentToken      string                 `json:"payment_token,omitempty"`
+	SavePaymentMethod bool                   `json:"save_payment_method,omitempty"`
+	ClientIP          string                 `json:"client_ip,omitempty"`
+	Metadata          map[string]string      `json:"metadata,omitempty"`
+}
+
+type PaymentMethodData struct {
+	Type  yoomodel.PaymentMethodType `json:"type"`
+	Phone string                     `json:"phone,omitempty"`
+}
+
+func (c *Client) CreatePayment(ctx context.Context, payment *PaymentRequest, idempotencyKey string) (*http.Response, error) {
+	jsonData, err := json.Marshal(payment)
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling payment: %w", err)
+	}
+
+	return c.makeRequest(ctx, http.MethodPost, PaymentEndpoint, "", jsonData, nil, idempotencyKey)
+}
@@ -0,0 +1,44 @@
+package yooapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	yoomodel "github.com/eclipsemode/go-yookassa-sdk/yookassa/model"
+	"net/http"
+)
+
+// PayoutRequest is the body of a new payout. The SDK payout model sends the destination as
+// payout_destination, which the API only uses in responses, so it is only used for responses.
+type PayoutRequest struct {
+	Amount                yoomodel.Amount        `json:"amount"`
+	PayoutToken           string                 `json:"payout_token,omitempty"`
+	PayoutDestinationData *PayoutDestinationData `json:"payout_destination_data,omitempty"`
+	Description           string                 `json:"description,omitempty"`
+	Metadata              map[string]string      `json:"metadata,omitempty"`
+}
+
+type PayoutDestinationData struct {
+	Type          yoomodel.PaymentMethodType `json:"type"`
+	Card          *PayoutCard                `json:"card,omitempty"`
+	BankID        string                     `json:"bank_id,omitempty"`
+	Phone         string                     `json:"phone,omitempty"`
+	AccountNumber string                     `json:"account_number,omitempty"`
+}
+
+type PayoutCard struct {
+	Number string `json:"number"`
+}
+
+func (c *Client) CreatePayout(ctx context.Context, payout *PayoutRequest, idempotencyKey string) (*http.Response, error) {
+	jsonData, err := json.Marshal(payout)
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling payout: %w", err)
+	}
+
+	return c.makeRequest(ctx, http.MethodPost, PayoutEndpoint, "", jsonData, nil, idempotencyKey)
+}
+
+func (c *Client) GetPayoutInfo(ctx context.Context, id string) (*http.Response, error) {
+	return c.makeRequest(ctx, http.MethodGet, PayoutEndpoint, id, nil, nil, "")
+}
@@ -0,0 +1,33 @@
+package yooapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	yoomodel "github.com/eclipsemode/go-yookassa-sdk/yookassa/model"
+	"net/http"
+	"time"
+)
+
+// Refund is the YooKassa refund object, which go-yookassa-sdk does not model.
+type Refund struct {
+	ID          string                     `json:"id,omitempty"`
+	PaymentID   string                     `json:"payment_id"`
+	Status      yoomodel.TransactionStatus `json:"status,omitempty"`
+	Amount      yoomodel.Amount            `json:"amount"`
+	Description string                     `json:"description,omitempty"`
+	CreatedAt   *time.Time                 `json:"created_at,omitempty"`
+}
+
+func (c *Client) CreateRefund(ctx context.Context, refund *Refund, idempotencyKey string) (*http.Response, error) {
+	jsonData, err := json.Marshal(refund)
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling refund: %w", err)
+	}
+
+	return c.makeRequest(ctx, http.MethodPost, RefundEndpoint, "", jsonData, nil, idempotencyKey)
+}
+
+func (c *Client) GetRefundInfo(ctx context.Context, id string) (*http.Response, error) {
+	return c.makeRequest(ctx, http.MethodGet, RefundEndpoint, id, nil, nil, "")
+}
@@ -0,0 +1,60 @@
+package yooapi
+
+import (
+	"errors"
+	"expvar"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// maxRetryBackoff caps the pause between attempts, including the one asked for with Retry-After.
+const maxRetryBackoff = 5 * time.Second
+
+// ErrUnavailable is returned when YooKassa could not be reached or kept failing until the attempts ran out,
+// and when the circuit breaker is open.
+var ErrUnavailable = errors.New("YooKassa is unavailable")
+
+// callMetrics is published at /debug/vars. retries counts repeated attempts, unavailable counts calls
+// that ended with ErrUnavailable and rejected_by_breaker the ones that were not made at all.
+var callMetrics = expvar.NewMap("yookassa_calls")
+
+// Policy bounds the calls to YooKassa. Timeout applies to every attempt, reading the body included.
+// Failed attempts are retried MaxRetries times with exponential backoff starting at RetryBackoff.
+// BreakerThreshold failed attempts in a row open the circuit breaker for BreakerCooldown.
+type Policy struct {
+	Timeout          time.Duration
+	MaxRetries       int
+	RetryBackoff     time.Duration
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+}
+
+// retryableStatus reports whether YooKassa asks to repeat the request: 429 and 5xx. Its docs ask to repeat
+// requests answered with 500 using the same idempotence key until the result is known.
+func retryableStatus(code int) bool {
+	return code == http.StatusTooManyRequests || code >= http.StatusInternalServerError
+}
+
+// backoff returns the pause before the next attempt: the delay doubled for every attempt made, half of it
+// random so that callers retrying together spread out. Retry-After of the last response is honored.
+func (p Policy) backoff(attempt int, res *http.Response) time.Duration {
+	if res != nil {
+		if seconds, err := strconv.Atoi(res.Header.Get("Retry-After")); err == nil && seconds > 0 {
+			return min(time.Duration(seconds)*time.Second, maxRetryBackoff)
+		}
+	}
+
+	delay := p.RetryBackoff
+	for i := 1; i < attempt && delay < maxRetryBackoff; i++ {
+		delay *= 2
+	}
+
+	delay = min(delay, maxRetryBackoff)
+	if delay <= 0 {
+		return 0
+	}
+
+	return delay/2 + rand.N(delay/2+1)
+}
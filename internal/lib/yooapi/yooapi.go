@@ -0,0 +1,169 @@
+// Package yooapi is the YooKassa API client. go-yookassa-sdk is only used for its models: its client can not
+// be pointed at another address and misses request fields we send. Methods return the raw *http.Response
+// for the caller to decode. Calls are bounded by a Policy: failed attempts are retried and a circuit
+// breaker stops calling YooKassa while it is down, so callers get ErrUnavailable instead of waiting.
+package yooapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	yoomodel "github.com/eclipsemode/go-yookassa-sdk/yookassa/model"
+	"github.com/imperatorofdwelling/payment-svc/internal/lib/breaker"
+	"go.uber.org/zap"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const DefaultApiAddr = "https://api.yookassa.ru/v3"
+
+type Endpoint string
+
+const (
+	PaymentEndpoint Endpoint = "payments"
+	PayoutEndpoint  Endpoint = "payouts"
+	RefundEndpoint  Endpoint = "refunds"
+)
+
+type Client struct {
+	client          http.Client
+	addr            string
+	shopID          int
+	secretKey       string
+	payoutAgentID   int
+	payoutSecretKey string
+	policy          Policy
+	breaker         *breaker.Breaker
+	log             *zap.SugaredLogger
+}
+
+func NewClient(addr string, shopID int, secretKey string, payoutAgentID int, payoutSecretKey string, policy Policy, log *zap.SugaredLogger) *Client {
+	if addr == "" {
+		addr = DefaultApiAddr
+	}
+
+	return &Client{
+		client:          http.Client{Timeout: policy.Timeout},
+		addr:            addr,
+		shopID:          shopID,
+		secretKey:       secretKey,
+		payoutAgentID:   payoutAgentID,
+		payoutSecretKey: payoutSecretKey,
+		policy:          policy,
+		breaker: breaker.New(policy.BreakerThreshold, policy.BreakerCooldown, func(from, to breaker.State) {
+			log.Warnf("YooKassa circuit breaker: %s -> %s", from, to)
+		}),
+		log: log,
+	}
+}
+
+func (c *Client) GetPaymentInfo(ctx context.Context, id string) (*http.Response, error) {
+	return c.makeRequest(ctx, http.MethodGet, PaymentEndpoint, id, nil, nil, "")
+}
+
+type captureReq struct {
+	Amount *yoomodel.Amount `json:"amount,omitempty"`
+}
+
+// CapturePayment confirms a payment in waiting_for_capture status. A nil amount captures the full payment amount.
+func (c *Client) CapturePayment(ctx context.Context, id string, amount *yoomodel.Amount, idempotencyKey string) (*http.Response, error) {
+	jsonData, err := json.Marshal(captureReq{Amount: amount})
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling capture: %w", err)
+	}
+
+	return c.makeRequest(ctx, http.MethodPost, PaymentEndpoint, id+"/capture", jsonData, nil, idempotencyKey)
+}
+
+// CancelPayment releases the funds held by a payment in waiting_for_capture status.
+func (c *Client) CancelPayment(ctx context.Context, id string, idempotencyKey string) (*http.Response, error) {
+	return c.makeRequest(ctx, http.MethodPost, PaymentEndpoint, id+"/cancel", []byte("{}"), nil, idempotencyKey)
+}
+
+// makeRequest calls YooKassa, repeating the attempts that failed with a network error, 429 or 5xx. Only reads
+// and requests with an idempotence key are repeated, YooKassa treats a repeated key as the same request.
+// The response is only returned when it is not to be retried, otherwise the call ends with ErrUnavailable.
+func (c *Client) makeRequest(ctx context.Context, method string, endpoint Endpoint, path string, body []byte, query url.Values, idempotencyKey string) (*http.Response, error) {
+	attempts := 1
+	if method == http.MethodGet || idempotencyKey != "" {
+		attempts += max(c.policy.MaxRetries, 0)
+	}
+
+	for attempt := 1; ; attempt++ {
+		if err := c.breaker.Allow(); err != nil {
+			callMetrics.Add("rejected_by_breaker", 1)
+			return nil, fmt.Errorf("%w: %w", ErrUnavailable, err)
+		}
+
+		res, err := c.do(ctx, method, endpoint, path, body, query, idempotencyKey)
+
+		var failure error
+
+		switch {
+		case ctx.Err() != nil:
+			c.breaker.Ignore()
+			if res != nil {
+				res.Body.Close()
+			}
+			return nil, ctx.Err()
+		case err != nil:
+			c.breaker.Failure()
+			failure = err
+		case retryableStatus(res.StatusCode):
+			c.breaker.Failure()
+			res.Body.Close()
+			failure = fmt.Errorf("%s %s answered with %s", method, res.Request.URL.Path, res.Status)
+		default:
+			c.breaker.Success()
+			return res, nil
+		}
+
+		if attempt >= attempts || c.breaker.State() == breaker.Open {
+			callMetrics.Add("unavailable", 1)
+			return nil, fmt.Errorf("%w: %w", ErrUnavailable, failure)
+		}
+
+		delay := c.policy.backoff(attempt, res)
+
+		c.log.Warnf("attempt %d of %d failed, retrying in %s: %v", attempt, attempts, delay, failure)
+		callMetrics.Add("retries", 1)
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func (c *Client) do(ctx context.Context, method string, endpoint Endpoint, path string, body []byte, query url.Values, idempotencyKey string) (*http.Response, error) {
+	uri := fmt.Sprintf("%s/%s", c.addr, endpoint)
+	if path != "" {
+		uri = fmt.Sprintf("%s/%s", uri, path)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, uri, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	if query != nil {
+		req.URL.RawQuery = query.Encode()
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	if idempotencyKey != "" {
+		req.Header.Set("Idempotence-Key", idempotencyKey)
+	}
+
+	if endpoint == PayoutEndpoint {
+		req.SetBasicAuth(strconv.Itoa(c.payoutAgentID), c.payoutSecretKey)
+	} else {
+		req.SetBasicAuth(strconv.Itoa(c.shopID), c.secretKey)
+	}
+
+	return c.client.Do(req)
+}
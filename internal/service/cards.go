@@ -13,6 +13,8 @@ import (
 type ICardsSvc interface {
 	CreateBankCard(ctx context.Context, card model.Card) error
 	DeleteCardByID(ctx context.Context, cardID uuid.UUID) error
+	GetCardByPayoutToken(ctx context.Context, token string) (*model.Card, error)
+	GetCardByID(ctx context.Context, cardID uuid.UUID) (*model.Card, error)
 }
 
 type CardsSvc struct {
@@ -75,3 +77,25 @@ func (s *CardsSvc) DeleteCardByID(ctx context.Context, cardID uuid.UUID) error {
 
 	return nil
 }
+
+func (s *CardsSvc) GetCardByPayoutToken(ctx context.Context, token string) (*model.Card, error) {
+	const op = "service.cards.GetCardByPayoutToken"
+
+	card, err := s.repo.GetCardByPayoutToken(ctx, token)
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	return card, nil
+}
+
+func (s *CardsSvc) GetCardByID(ctx context.Context, cardID uuid.UUID) (*model.Card, error) {
+	const op = "service.cards.GetCardByID"
+
+	card, err := s.repo.GetCardByID(ctx, cardID)
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	return card, nil
+}
@@ -1,14 +1,70 @@
 package service
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	yoomodel "github.com/eclipsemode/go-yookassa-sdk/yookassa/model"
+)
 
 var (
 	ErrCardAlreadyExists = errors.New("card already exists")
 )
 
+// payment errors
+var (
+	ErrPaymentNotWaitingForCapture = errors.New("payment is not waiting for capture")
+	ErrCurrencyMismatch            = errors.New("currency does not match payment currency")
+	ErrCaptureAmountExceeded       = errors.New("capture amount exceeds payment amount")
+	ErrInvalidAmount               = errors.New("invalid amount")
+)
+
+// refund errors
+var (
+	ErrPaymentNotRefundable = errors.New("payment is not captured and cannot be refunded")
+	ErrRefundAmountExceeded = errors.New("refunds exceed captured payment amount")
+)
+
 // payoutsubscriber errors
 var (
 	ErrNoNeedToCheck      = errors.New("no need to check")
 	ErrCannotStartToCheck = errors.New("cannot start to check")
-	ErrEmptyResponse      = errors.New("empty response")
 )
+
+// notification errors
+var (
+	ErrUnknownNotificationEvent = errors.New("unknown notification event")
+	ErrNotificationNotVerified  = errors.New("notification object is not found in YooKassa")
+)
+
+// scheduled payout errors
+var (
+	ErrExecuteAtInPast              = errors.New("execution time must be in the future")
+	ErrScheduledPayoutNotCancelable = errors.New("scheduled payout is not pending and cannot be canceled")
+)
+
+// payout rule errors
+var (
+	ErrCardNotOwnedByUser = errors.New("card does not belong to the user")
+)
+
+// logs errors
+var (
+	ErrIllegalStatusTransition = errors.New("illegal transaction status transition")
+	ErrConcurrentStatusUpdate  = errors.New("transaction status keeps changing concurrently")
+)
+
+// StatusTransitionError is returned when a status update violates the transaction state machine.
+// It matches ErrIllegalStatusTransition with errors.Is.
+type StatusTransitionError struct {
+	TransactionID string
+	From          yoomodel.TransactionStatus
+	To            yoomodel.TransactionStatus
+}
+
+func (e *StatusTransitionError) Error() string {
+	return fmt.Sprintf("%s: %s from %q to %q", ErrIllegalStatusTransition, e.TransactionID, e.From, e.To)
+}
+
+func (e *StatusTransitionError) Unwrap() error {
+	return ErrIllegalStatusTransition
+}
@@ -0,0 +1,176 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/eclipsemode/go-yookassa-sdk/yookassa/model"
+	"github.com/google/uuid"
+	"github.com/imperatorofdwelling/payment-svc/internal/domain/model"
+	"github.com/imperatorofdwelling/payment-svc/internal/storage/postgres"
+	"go.uber.org/zap"
+)
+
+// ILedgerSvc keeps user balances. Post methods are called with the stored transaction after every
+// status change and only post what the status calls for; each event is posted once however often it is seen.
+type ILedgerSvc interface {
+	PostPayment(ctx context.Context, payment *model.Payment) error
+	PostRefund(ctx context.Context, refund *model.Refund, payment *model.Payment) error
+	PostPayout(ctx context.Context, payout *model.Payout) error
+	GetUser(ctx context.Context, userID uuid.UUID) (*model.User, error)
+	GetBalance(ctx context.Context, userID uuid.UUID, currency yoomodel.Currency) (string, error)
+	GetLedger(ctx context.Context, filter model.LedgerFilter) (*model.LedgerPage, error)
+}
+
+type LedgerSvc struct {
+	repo postgres.ILedgerRepo
+	log  *zap.SugaredLogger
+}
+
+func NewLedgerService(repo postgres.ILedgerRepo, log *zap.SugaredLogger) *LedgerSvc {
+	return &LedgerSvc{repo, log}
+}
+
+// PostPayment credits a succeeded payment to the user it was made for.
+func (s *LedgerSvc) PostPayment(ctx context.Context, payment *model.Payment) error {
+	const op = "service.ledger.PostPayment"
+
+	if payment.Status != yoomodel.Succeeded {
+		return nil
+	}
+
+	userID, ok := payment.UserID()
+	if !ok {
+		return nil
+	}
+
+	err := s.post(ctx, model.PaymentPosting, payment.PaymentID, userID, payment.Amount, true)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// PostRefund debits a succeeded refund from the user the refunded payment was credited to.
+func (s *LedgerSvc) PostRefund(ctx context.Context, refund *model.Refund, payment *model.Payment) error {
+	const op = "service.ledger.PostRefund"
+
+	if refund.Status != yoomodel.Succeeded {
+		return nil
+	}
+
+	userID, ok := payment.UserID()
+	if !ok {
+		return nil
+	}
+
+	amount, err := model.ParseMoney(refund.Value, refund.Currency)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = s.post(ctx, model.RefundPosting, refund.RefundID, userID, amount, false)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// PostPayout debits the user as soon as a payout is made, so the money can not be paid out twice while
+// YooKassa processes it. A canceled payout is credited back.
+func (s *LedgerSvc) PostPayout(ctx context.Context, payout *model.Payout) error {
+	const op = "service.ledger.PostPayout"
+
+	if payout.UserID == nil {
+		return nil
+	}
+
+	amount, err := model.ParseMoney(payout.Value, payout.Currency)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = s.post(ctx, model.PayoutPosting, payout.PayoutID, *payout.UserID, amount, false)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if payout.Status != yoomodel.Canceled {
+		return nil
+	}
+
+	err = s.post(ctx, model.PayoutReversalPosting, payout.PayoutID, *payout.UserID, amount, true)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *LedgerSvc) post(ctx context.Context, kind model.PostingKind, referenceID string, userID uuid.UUID, amount model.Money, credit bool) error {
+	posting, err := model.NewUserPosting(kind, referenceID, userID, amount, credit)
+	if err != nil {
+		return err
+	}
+
+	posted, err := s.repo.InsertPosting(ctx, posting)
+	if err != nil {
+		return err
+	}
+
+	if posted {
+		s.log.Infof("posted %s %s: %s for user %s", kind, referenceID, posting.Entries[0].Value, userID)
+	}
+
+	return nil
+}
+
+// GetUser returns the user with balances in every currency the user has ever been credited or debited in.
+func (s *LedgerSvc) GetUser(ctx context.Context, userID uuid.UUID) (*model.User, error) {
+	const op = "service.ledger.GetUser"
+
+	balances, err := s.repo.GetBalances(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &model.User{ID: userID, Balances: balances}, nil
+}
+
+func (s *LedgerSvc) GetBalance(ctx context.Context, userID uuid.UUID, currency yoomodel.Currency) (string, error) {
+	const op = "service.ledger.GetBalance"
+
+	balance, err := s.repo.GetBalance(ctx, userID, currency)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	return balance, nil
+}
+
+// GetLedger returns one page of the user balance changes. NextCursor is only set when there are more entries.
+func (s *LedgerSvc) GetLedger(ctx context.Context, filter model.LedgerFilter) (*model.LedgerPage, error) {
+	const op = "service.ledger.GetLedger"
+
+	limit := filter.Limit
+
+	// one extra row tells whether the next page exists
+	filter.Limit++
+
+	changes, err := s.repo.GetBalanceChanges(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	page := &model.LedgerPage{Items: changes}
+
+	if len(changes) > limit {
+		page.Items = changes[:limit]
+
+		last := page.Items[limit-1]
+		page.NextCursor = model.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}.Encode()
+	}
+
+	return page, nil
+}
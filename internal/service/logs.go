@@ -9,9 +9,19 @@ import (
 	"go.uber.org/zap"
 )
 
+// maxStatusUpdateAttempts bounds retries when concurrent updaters keep changing the status underneath.
+const maxStatusUpdateAttempts = 3
+
+// exportPageSize is how many logs an export reads per query.
+const exportPageSize = 1000
+
 type ILogsSvc interface {
-	InsertLog(ctx context.Context, log *model.Log) error
-	UpdateLogTransactionStatus(ctx context.Context, transactionId string, status yoomodel.TransactionStatus) error
+	InsertLog(ctx context.Context, log *model.Log, source model.StatusSource) error
+	UpdateLogTransactionStatus(ctx context.Context, transactionId string, status yoomodel.TransactionStatus, source model.StatusSource) error
+	GetLogByTransactionID(ctx context.Context, transactionId string) (*model.Log, error)
+	GetStatusHistory(ctx context.Context, transactionId string) ([]model.StatusHistory, error)
+	GetLogs(ctx context.Context, filter model.LogFilter) (*model.LogsPage, error)
+	ExportLogs(ctx context.Context, filter model.LogFilter, fn func(model.Log) error) error
 }
 
 type LogsSvc struct {
@@ -23,10 +33,10 @@ func NewLogsService(repo postgres.ILogsRepo, log *zap.SugaredLogger) *LogsSvc {
 	return &LogsSvc{repo, log}
 }
 
-func (s *LogsSvc) InsertLog(ctx context.Context, log *model.Log) error {
+func (s *LogsSvc) InsertLog(ctx context.Context, log *model.Log, source model.StatusSource) error {
 	const op = "service.logs.InsertLog"
 
-	err := s.repo.InsertLog(ctx, log)
+	err := s.repo.InsertLog(ctx, log, source)
 	if err != nil {
 		return err
 	}
@@ -34,13 +44,121 @@ func (s *LogsSvc) InsertLog(ctx context.Context, log *model.Log) error {
 	return nil
 }
 
-func (s *LogsSvc) UpdateLogTransactionStatus(ctx context.Context, transactionId string, status yoomodel.TransactionStatus) error {
+// UpdateLogTransactionStatus applies a status change through the transaction state machine.
+// Repeating the current status is a no-op, an illegal transition returns a *StatusTransitionError.
+func (s *LogsSvc) UpdateLogTransactionStatus(ctx context.Context, transactionId string, status yoomodel.TransactionStatus, source model.StatusSource) error {
 	const op = "service.logs.UpdateLogStatus"
 
-	err := s.repo.UpdateLogStatus(ctx, transactionId, status)
+	for attempt := 0; attempt < maxStatusUpdateAttempts; attempt++ {
+		current, err := s.repo.GetLogByTransactionID(ctx, transactionId)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		if current.Status == status {
+			return nil
+		}
+
+		if !model.CanTransition(current.Status, status) {
+			return fmt.Errorf("%s: %w", op, &StatusTransitionError{TransactionID: transactionId, From: current.Status, To: status})
+		}
+
+		updated, err := s.repo.UpdateLogStatus(ctx, transactionId, current.Status, status, source)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		if updated {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%s: %w", op, ErrConcurrentStatusUpdate)
+}
+
+func (s *LogsSvc) GetLogByTransactionID(ctx context.Context, transactionId string) (*model.Log, error) {
+	const op = "service.logs.GetLogByTransactionID"
+
+	l, err := s.repo.GetLogByTransactionID(ctx, transactionId)
 	if err != nil {
-		return fmt.Errorf("%s: %w", op, err)
+		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	return nil
+	return l, nil
+}
+
+// GetStatusHistory returns every status change of the transaction in chronological order.
+func (s *LogsSvc) GetStatusHistory(ctx context.Context, transactionId string) ([]model.StatusHistory, error) {
+	const op = "service.logs.GetStatusHistory"
+
+	exists, err := s.repo.CheckTransactionIDExists(ctx, transactionId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if !exists {
+		return nil, fmt.Errorf("%s: %w", op, postgres.ErrLogNotFound)
+	}
+
+	history, err := s.repo.GetStatusHistory(ctx, transactionId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return history, nil
+}
+
+// GetLogs returns one page of logs. NextCursor is only set when there are more logs after the page.
+func (s *LogsSvc) GetLogs(ctx context.Context, filter model.LogFilter) (*model.LogsPage, error) {
+	const op = "service.logs.GetLogs"
+
+	limit := filter.Limit
+
+	// one extra row tells whether the next page exists
+	filter.Limit++
+
+	logs, err := s.repo.GetLogs(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	page := &model.LogsPage{Items: logs}
+
+	if len(logs) > limit {
+		page.Items = logs[:limit]
+
+		last := page.Items[limit-1]
+		page.NextCursor = model.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}.Encode()
+	}
+
+	return page, nil
+}
+
+// ExportLogs walks through every log matching the filter page by page and passes each one to fn.
+// Limit and Cursor of the filter are ignored.
+func (s *LogsSvc) ExportLogs(ctx context.Context, filter model.LogFilter, fn func(model.Log) error) error {
+	const op = "service.logs.ExportLogs"
+
+	filter.Limit = exportPageSize
+	filter.Cursor = nil
+
+	for {
+		logs, err := s.repo.GetLogs(ctx, filter)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		for _, l := range logs {
+			if err := fn(l); err != nil {
+				return fmt.Errorf("%s: %w", op, err)
+			}
+		}
+
+		if len(logs) < exportPageSize {
+			return nil
+		}
+
+		last := logs[len(logs)-1]
+		filter.Cursor = &model.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}
+	}
 }
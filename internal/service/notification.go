@@ -0,0 +1,175 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	yoomodel "github.com/eclipsemode/go-yookassa-sdk/yookassa/model"
+	"github.com/imperatorofdwelling/payment-svc/internal/domain/model"
+	"github.com/imperatorofdwelling/payment-svc/internal/gateway"
+	"github.com/imperatorofdwelling/payment-svc/internal/storage/postgres"
+	"github.com/imperatorofdwelling/payment-svc/internal/storage/redis"
+	"go.uber.org/zap"
+	"time"
+)
+
+type INotificationSvc interface {
+	HandleNotification(ctx context.Context, notification model.Notification) error
+}
+
+type NotificationSvc struct {
+	rdbNotification redis.INotificationRepo
+	paymentGateway  gateway.PaymentGateway
+	payoutGateway   gateway.PayoutGateway
+	paymentSvc      IPaymentSvc
+	refundsSvc      IRefundsSvc
+	payoutsSvc      IPayoutsSvc
+	dedupTTL        time.Duration
+	log             *zap.SugaredLogger
+}
+
+func NewNotificationService(
+	rdbNotification redis.INotificationRepo,
+	paymentGateway gateway.PaymentGateway,
+	payoutGateway gateway.PayoutGateway,
+	paymentSvc IPaymentSvc,
+	refundsSvc IRefundsSvc,
+	payoutsSvc IPayoutsSvc,
+	dedupTTL time.Duration,
+	log *zap.SugaredLogger,
+) *NotificationSvc {
+	return &NotificationSvc{rdbNotification, paymentGateway, payoutGateway, paymentSvc, refundsSvc, payoutsSvc, dedupTTL, log}
+}
+
+// HandleNotification applies a YooKassa webhook. The notification body is never trusted: the object is
+// re-fetched from the API and only its actual state is stored. Repeated deliveries of a confirmed event
+// are acknowledged without being processed again.
+func (s *NotificationSvc) HandleNotification(ctx context.Context, notification model.Notification) error {
+	const op = "service.notification.HandleNotification"
+
+	dedupKey := string(notification.Event) + ":" + notification.Object.ID
+
+	acquired, err := s.rdbNotification.Acquire(dedupKey, s.dedupTTL)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if !acquired {
+		s.log.Infof("%s: skipping repeated notification %s", op, dedupKey)
+		return nil
+	}
+
+	actualStatus, err := s.dispatch(ctx, notification)
+
+	// Only an event confirmed by the API stays marked as processed, otherwise a stale or forged
+	// notification would suppress the genuine delivery of the same event.
+	if err != nil || actualStatus != notification.Event.Status() {
+		if releaseErr := s.rdbNotification.Release(dedupKey); releaseErr != nil {
+			s.log.Errorf("%s: %v", op, releaseErr)
+		}
+	}
+
+	if errors.Is(err, ErrIllegalStatusTransition) {
+		s.log.Warnf("%s: ignoring %s: %v", op, dedupKey, err)
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if actualStatus != notification.Event.Status() {
+		s.log.Warnf("%s: %s does not match actual status %q, stored the actual one", op, dedupKey, actualStatus)
+	}
+
+	return nil
+}
+
+// dispatch syncs the object the notification is about and returns its actual status.
+func (s *NotificationSvc) dispatch(ctx context.Context, notification model.Notification) (yoomodel.TransactionStatus, error) {
+	switch notification.Event {
+	case model.PaymentSucceededEvent, model.PaymentWaitingForCaptureEvent, model.PaymentCanceledEvent:
+		return s.handlePayment(ctx, notification)
+	case model.RefundSucceededEvent:
+		return s.handleRefund(ctx, notification)
+	case model.PayoutSucceededEvent, model.PayoutCanceledEvent:
+		return s.handlePayout(ctx, notification)
+	default:
+		return "", ErrUnknownNotificationEvent
+	}
+}
+
+func (s *NotificationSvc) handlePayment(ctx context.Context, notification model.Notification) (yoomodel.TransactionStatus, error) {
+	payment, err := s.paymentGateway.GetPayment(ctx, notification.Object.ID)
+	if err != nil {
+		return "", s.notFoundToUnverified(notification, err)
+	}
+
+	if err := s.verify(notification, payment.PaymentID); err != nil {
+		return "", err
+	}
+
+	_, err = s.paymentSvc.SyncPayment(ctx, payment, model.WebhookSource)
+	if errors.Is(err, postgres.ErrPaymentNotFound) {
+		s.log.Warnf("payment %s is missing locally, restoring it from notification", payment.PaymentID)
+		err = s.paymentSvc.CreatePayment(ctx, payment, "", model.WebhookSource)
+	}
+
+	return payment.Status, err
+}
+
+func (s *NotificationSvc) handleRefund(ctx context.Context, notification model.Notification) (yoomodel.TransactionStatus, error) {
+	refund, err := s.paymentGateway.GetRefund(ctx, notification.Object.ID)
+	if err != nil {
+		return "", s.notFoundToUnverified(notification, err)
+	}
+
+	if err := s.verify(notification, refund.RefundID); err != nil {
+		return "", err
+	}
+
+	_, err = s.refundsSvc.SyncRefund(ctx, refund, model.WebhookSource)
+	if errors.Is(err, postgres.ErrRefundNotFound) {
+		s.log.Warnf("refund %s is missing locally, restoring it from notification", refund.RefundID)
+		_, err = s.refundsSvc.CreateRefund(ctx, refund, "", model.WebhookSource)
+	}
+
+	return refund.Status, err
+}
+
+func (s *NotificationSvc) handlePayout(ctx context.Context, notification model.Notification) (yoomodel.TransactionStatus, error) {
+	payout, err := s.payoutGateway.GetPayout(ctx, notification.Object.ID)
+	if err != nil {
+		return "", s.notFoundToUnverified(notification, err)
+	}
+
+	if err := s.verify(notification, payout.PayoutID); err != nil {
+		return "", err
+	}
+
+	err = s.payoutsSvc.SyncPayout(ctx, payout, model.WebhookSource)
+	if errors.Is(err, postgres.ErrPayoutNotFound) {
+		s.log.Warnf("payout %s is missing locally, restoring it from notification", payout.PayoutID)
+		err = s.payoutsSvc.CreatePayout(ctx, payout, model.WebhookSource)
+	}
+
+	return payout.Status, err
+}
+
+// verify checks that the object fetched from the provider is the one the notification is about.
+func (s *NotificationSvc) verify(notification model.Notification, id string) error {
+	if id == "" || id != notification.Object.ID {
+		return fmt.Errorf("%w: %s %s", ErrNotificationNotVerified, notification.Event, notification.Object.ID)
+	}
+
+	return nil
+}
+
+// notFoundToUnverified reports a notification about an object the provider does not know as not verified.
+func (s *NotificationSvc) notFoundToUnverified(notification model.Notification, err error) error {
+	if errors.Is(err, gateway.ErrNotFound) {
+		return fmt.Errorf("%w: %s %s", ErrNotificationNotVerified, notification.Event, notification.Object.ID)
+	}
+
+	return err
+}
@@ -10,38 +10,157 @@ import (
 )
 
 type IPaymentSvc interface {
-	CreatePayment(context.Context, *yoomodel.Payment) error
+	CreatePayment(ctx context.Context, payment *model.Payment, idempotenceKey string, source model.StatusSource) error
+	GetPaymentByID(ctx context.Context, paymentID string) (*model.Payment, error)
+	SyncPayment(ctx context.Context, payment *model.Payment, source model.StatusSource) (*model.Payment, error)
+	CheckCapture(ctx context.Context, paymentID string, amount *model.Money) error
+	CheckCancel(ctx context.Context, paymentID string) error
 }
 
 type PaymentSvc struct {
-	repo    postgres.IPaymentRepo
-	log     *zap.SugaredLogger
-	logsSvc ILogsSvc
+	repo      postgres.IPaymentRepo
+	log       *zap.SugaredLogger
+	logsSvc   ILogsSvc
+	ledgerSvc ILedgerSvc
 }
 
-func NewPaymentSvc(repo postgres.IPaymentRepo, logsSvc ILogsSvc, log *zap.SugaredLogger) *PaymentSvc {
+func NewPaymentSvc(repo postgres.IPaymentRepo, logsSvc ILogsSvc, ledgerSvc ILedgerSvc, log *zap.SugaredLogger) *PaymentSvc {
 	return &PaymentSvc{
 		repo,
 		log,
 		logsSvc,
+		ledgerSvc,
 	}
 }
 
-func (s *PaymentSvc) CreatePayment(ctx context.Context, payment *yoomodel.Payment) error {
+// CreatePayment stores the payment created at the provider.
+func (s *PaymentSvc) CreatePayment(ctx context.Context, payment *model.Payment, idempotenceKey string, source model.StatusSource) error {
 	const op = "service.payments.CreatePayment"
 
+	payment.IdempotenceKey = idempotenceKey
+
 	newLog := &model.Log{
-		TransactionID:   payment.ID,
+		TransactionID:   payment.PaymentID,
 		TransactionType: yoomodel.PaymentType,
 		Status:          payment.Status,
-		Value:           payment.Amount.Value,
-		Currency:        payment.Amount.Currency,
+		Amount:          payment.Amount,
+	}
+
+	err := s.logsSvc.InsertLog(ctx, newLog, source)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = s.repo.InsertPayment(ctx, payment)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
 	}
 
-	err := s.logsSvc.InsertLog(ctx, newLog)
+	err = s.ledgerSvc.PostPayment(ctx, payment)
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
 	return nil
 }
+
+func (s *PaymentSvc) GetPaymentByID(ctx context.Context, paymentID string) (*model.Payment, error) {
+	const op = "service.payments.GetPaymentByID"
+
+	payment, err := s.repo.GetPaymentByID(ctx, paymentID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return payment, nil
+}
+
+// SyncPayment stores the actual payment state received from the provider. A status change goes through the logs
+// state machine first, so a stale state is rejected before anything is written.
+func (s *PaymentSvc) SyncPayment(ctx context.Context, actual *model.Payment, source model.StatusSource) (*model.Payment, error) {
+	const op = "service.payments.SyncPayment"
+
+	stored, err := s.repo.GetPaymentByID(ctx, actual.PaymentID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	actual.IdempotenceKey = stored.IdempotenceKey
+
+	if stored.Status != actual.Status {
+		err = s.logsSvc.UpdateLogTransactionStatus(ctx, actual.PaymentID, actual.Status, source)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	err = s.repo.UpdatePayment(ctx, actual)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	updated, err := s.repo.GetPaymentByID(ctx, actual.PaymentID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = s.ledgerSvc.PostPayment(ctx, updated)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return updated, nil
+}
+
+// CheckCapture verifies that the stored payment is held and the optional partial amount fits into it.
+func (s *PaymentSvc) CheckCapture(ctx context.Context, paymentID string, amount *model.Money) error {
+	const op = "service.payments.CheckCapture"
+
+	payment, err := s.repo.GetPaymentByID(ctx, paymentID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if payment.Status != yoomodel.WaitingForCapture {
+		return fmt.Errorf("%s: %w", op, ErrPaymentNotWaitingForCapture)
+	}
+
+	if amount == nil {
+		return nil
+	}
+
+	if amount.Currency != payment.Amount.Currency {
+		return fmt.Errorf("%s: %w", op, ErrCurrencyMismatch)
+	}
+
+	if amount.IsZero() || amount.IsNegative() {
+		return fmt.Errorf("%s: %w: %s", op, ErrInvalidAmount, amount)
+	}
+
+	cmp, err := amount.Cmp(payment.Amount)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if cmp > 0 {
+		return fmt.Errorf("%s: %w", op, ErrCaptureAmountExceeded)
+	}
+
+	return nil
+}
+
+// CheckCancel verifies that the stored payment is held and can be canceled.
+func (s *PaymentSvc) CheckCancel(ctx context.Context, paymentID string) error {
+	const op = "service.payments.CheckCancel"
+
+	payment, err := s.repo.GetPaymentByID(ctx, paymentID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if payment.Status != yoomodel.WaitingForCapture {
+		return fmt.Errorf("%s: %w", op, ErrPaymentNotWaitingForCapture)
+	}
+
+	return nil
+}
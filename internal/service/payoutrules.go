@@ -0,0 +1,287 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/imperatorofdwelling/payment-svc/internal/domain/model"
+	"github.com/imperatorofdwelling/payment-svc/internal/gateway"
+	"github.com/imperatorofdwelling/payment-svc/internal/lib/scheduler"
+	"github.com/imperatorofdwelling/payment-svc/internal/storage/postgres"
+	"go.uber.org/zap"
+	"time"
+)
+
+// PayoutRuleJob is the scheduler job kind running recurring payout rules.
+const PayoutRuleJob = "payout_rule"
+
+type IPayoutRulesSvc interface {
+	CreatePayoutRule(ctx context.Context, rule *model.PayoutRule) error
+	GetPayoutRuleByID(ctx context.Context, id uuid.UUID) (*model.PayoutRule, error)
+	GetPayoutRules(ctx context.Context, userID *uuid.UUID) ([]model.PayoutRule, error)
+	UpdatePayoutRule(ctx context.Context, rule *model.PayoutRule) error
+	DeletePayoutRule(ctx context.Context, id uuid.UUID) error
+}
+
+type PayoutRulesSvc struct {
+	repo          postgres.IPayoutRulesRepo
+	ledgerSvc     ILedgerSvc
+	cardsSvc      ICardsSvc
+	payoutsSvc    IPayoutsSvc
+	payoutGateway gateway.PayoutGateway
+	scheduler     *scheduler.Scheduler
+	log           *zap.SugaredLogger
+}
+
+func NewPayoutRulesService(
+	repo postgres.IPayoutRulesRepo,
+	ledgerSvc ILedgerSvc,
+	cardsSvc ICardsSvc,
+	payoutsSvc IPayoutsSvc,
+	payoutGateway gateway.PayoutGateway,
+	scheduler *scheduler.Scheduler,
+	log *zap.SugaredLogger,
+) *PayoutRulesSvc {
+	s := &PayoutRulesSvc{repo, ledgerSvc, cardsSvc, payoutsSvc, payoutGateway, scheduler, log}
+
+	scheduler.RegisterHandler(PayoutRuleJob, s.run)
+
+	return s
+}
+
+type payoutRulePayload struct {
+	RuleID uuid.UUID `json:"rule_id"`
+	RunAt  time.Time `json:"run_at"`
+}
+
+func (s *PayoutRulesSvc) CreatePayoutRule(ctx context.Context, rule *model.PayoutRule) error {
+	const op = "service.payoutrules.CreatePayoutRule"
+
+	err := s.checkCard(ctx, rule)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = s.repo.InsertPayoutRule(ctx, rule)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = s.schedule(ctx, rule, time.Now(), nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *PayoutRulesSvc) GetPayoutRuleByID(ctx context.Context, id uuid.UUID) (*model.PayoutRule, error) {
+	const op = "service.payoutrules.GetPayoutRuleByID"
+
+	rule, err := s.repo.GetPayoutRuleByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return rule, nil
+}
+
+func (s *PayoutRulesSvc) GetPayoutRules(ctx context.Context, userID *uuid.UUID) ([]model.PayoutRule, error) {
+	const op = "service.payoutrules.GetPayoutRules"
+
+	rules, err := s.repo.GetPayoutRules(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return rules, nil
+}
+
+// UpdatePayoutRule saves new settings and reschedules the rule. The owner of a rule cannot be changed.
+func (s *PayoutRulesSvc) UpdatePayoutRule(ctx context.Context, rule *model.PayoutRule) error {
+	const op = "service.payoutrules.UpdatePayoutRule"
+
+	stored, err := s.repo.GetPayoutRuleByID(ctx, rule.ID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rule.UserID = stored.UserID
+	rule.LastRunAt = stored.LastRunAt
+	rule.CreatedAt = stored.CreatedAt
+
+	err = s.checkCard(ctx, rule)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = s.repo.UpdatePayoutRule(ctx, rule)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = s.cancelJob(ctx, stored)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = s.schedule(ctx, rule, time.Now(), nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *PayoutRulesSvc) DeletePayoutRule(ctx context.Context, id uuid.UUID) error {
+	const op = "service.payoutrules.DeletePayoutRule"
+
+	stored, err := s.repo.GetPayoutRuleByID(ctx, id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = s.cancelJob(ctx, stored)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = s.repo.DeletePayoutRule(ctx, id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *PayoutRulesSvc) checkCard(ctx context.Context, rule *model.PayoutRule) error {
+	card, err := s.cardsSvc.GetCardByID(ctx, rule.CardID)
+	if err != nil {
+		return err
+	}
+
+	if card.UserId != rule.UserID {
+		return ErrCardNotOwnedByUser
+	}
+
+	return nil
+}
+
+// schedule plans the next run of an enabled rule. A disabled rule is stored without a next run.
+func (s *PayoutRulesSvc) schedule(ctx context.Context, rule *model.PayoutRule, after time.Time, lastRunAt *time.Time) error {
+	if !rule.Enabled {
+		rule.JobID, rule.NextRunAt = nil, nil
+		return s.repo.SetPayoutRuleSchedule(ctx, rule.ID, nil, nil, lastRunAt)
+	}
+
+	next := rule.NextRun(after)
+
+	job, err := s.scheduler.ScheduleJob(ctx, PayoutRuleJob, payoutRulePayload{RuleID: rule.ID, RunAt: next}, next)
+	if err != nil {
+		return err
+	}
+
+	rule.JobID, rule.NextRunAt = &job.ID, &next
+
+	return s.repo.SetPayoutRuleSchedule(ctx, rule.ID, &job.ID, &next, lastRunAt)
+}
+
+func (s *PayoutRulesSvc) cancelJob(ctx context.Context, rule *model.PayoutRule) error {
+	if rule.JobID == nil {
+		return nil
+	}
+
+	_, err := s.scheduler.CancelJob(ctx, *rule.JobID)
+	return err
+}
+
+// run is the scheduler job handler. It sweeps the balance and plans the next run whatever the sweep outcome,
+// so one failed payout does not stop the rule.
+func (s *PayoutRulesSvc) run(ctx context.Context, payload []byte) error {
+	const op = "service.payoutrules.run"
+
+	var p payoutRulePayload
+
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rule, err := s.repo.GetPayoutRuleByID(ctx, p.RuleID)
+	if errors.Is(err, postgres.ErrPayoutRuleNotFound) {
+		s.log.Infof("%s: payout rule %s was deleted, skipping", op, p.RuleID)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	// the rule was rescheduled or disabled after this job was planned
+	if !rule.Enabled || rule.NextRunAt == nil || !rule.NextRunAt.Equal(p.RunAt) {
+		s.log.Infof("%s: stale run of payout rule %s, skipping", op, rule.ID)
+		return nil
+	}
+
+	sweepErr := s.sweep(ctx, rule, p.RunAt)
+
+	err = s.schedule(ctx, rule, p.RunAt, &p.RunAt)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if sweepErr != nil {
+		return fmt.Errorf("%s: %w", op, sweepErr)
+	}
+
+	return nil
+}
+
+// sweep pays the whole available balance out to the rule card if it reaches the rule threshold.
+// The idempotence key is derived from the rule and the run time, so a repeated run pays at most once.
+func (s *PayoutRulesSvc) sweep(ctx context.Context, rule *model.PayoutRule, runAt time.Time) error {
+	balance, err := s.ledgerSvc.GetBalance(ctx, rule.UserID, rule.Currency)
+	if err != nil {
+		return err
+	}
+
+	amount, err := model.ParseMoney(balance, rule.Currency)
+	if err != nil {
+		return err
+	}
+
+	threshold, err := model.ParseMoney(rule.MinAmount, rule.Currency)
+	if err != nil {
+		return err
+	}
+
+	cmp, err := amount.Cmp(threshold)
+	if err != nil {
+		return err
+	}
+
+	// a zero threshold must not turn an empty balance into a payout the provider rejects
+	if cmp < 0 || amount.IsZero() || amount.IsNegative() {
+		s.log.Infof("payout rule %s: balance %s is below threshold %s", rule.ID, amount, threshold)
+		return nil
+	}
+
+	card, err := s.cardsSvc.GetCardByID(ctx, rule.CardID)
+	if err != nil {
+		return err
+	}
+
+	idempotenceKey := uuid.NewSHA1(rule.ID, []byte(runAt.UTC().Format(time.RFC3339))).String()
+
+	created, err := s.payoutGateway.CreatePayout(ctx, &model.PayoutRequest{
+		Amount:      model.Amount{Value: amount.Decimal(), Currency: rule.Currency},
+		PayoutToken: card.PayoutToken,
+		Description: "Automatic settlement",
+		Metadata:    map[string]string{"payout_rule_id": rule.ID.String()},
+	}, idempotenceKey)
+	if err != nil {
+		return err
+	}
+
+	return s.payoutsSvc.CreatePayout(ctx, created, model.SchedulerSource)
+}
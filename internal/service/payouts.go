@@ -2,47 +2,78 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/eclipsemode/go-yookassa-sdk/yookassa/model"
 	"github.com/imperatorofdwelling/payment-svc/internal/domain/model"
-	"github.com/imperatorofdwelling/payment-svc/internal/lib/scheduler"
 	"github.com/imperatorofdwelling/payment-svc/internal/storage/postgres"
 	"go.uber.org/zap"
 )
 
 type IPayoutsSvc interface {
-	CreatePayout(ctx context.Context, payout yoomodel.Payout) error
-	SchedulePayout(ctx context.Context, payout yoomodel.Payout, scheduler *scheduler.Scheduler) error
+	CreatePayout(ctx context.Context, payout *model.Payout, source model.StatusSource) error
+	GetPayoutByID(ctx context.Context, payoutID string) (*model.Payout, error)
+	SyncPayout(ctx context.Context, payout *model.Payout, source model.StatusSource) error
 }
 
 type PayoutsSvc struct {
 	repo             postgres.IPayoutsRepo
+	cardsSvc         ICardsSvc
 	logsSvc          ILogsSvc
+	ledgerSvc        ILedgerSvc
 	payoutSubscriber IPayoutSubscriber
 	log              *zap.SugaredLogger
 }
 
-func NewPayoutsService(repo postgres.IPayoutsRepo, payoutSubscriber IPayoutSubscriber, logsSvc ILogsSvc, log *zap.SugaredLogger) *PayoutsSvc {
-	return &PayoutsSvc{repo, logsSvc, payoutSubscriber, log}
+func NewPayoutsService(repo postgres.IPayoutsRepo, cardsSvc ICardsSvc, payoutSubscriber IPayoutSubscriber, logsSvc ILogsSvc, ledgerSvc ILedgerSvc, log *zap.SugaredLogger) *PayoutsSvc {
+	return &PayoutsSvc{repo, cardsSvc, logsSvc, ledgerSvc, payoutSubscriber, log}
 }
 
-func (s *PayoutsSvc) CreatePayout(ctx context.Context, payout yoomodel.Payout) error {
+// CreatePayout stores the payout created at the provider. A payout to a saved card is attributed to the card
+// owner, payouts made with destination data in the request have no saved card.
+func (s *PayoutsSvc) CreatePayout(ctx context.Context, payout *model.Payout, source model.StatusSource) error {
 	const op = "service.payout.NewPayout"
 
+	if payout.PayoutToken != "" {
+		card, err := s.cardsSvc.GetCardByPayoutToken(ctx, payout.PayoutToken)
+		if err != nil && !errors.Is(err, postgres.ErrCardNotFound) {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		if card != nil {
+			payout.CardID = &card.ID
+			payout.UserID = &card.UserId
+		}
+	}
+
+	amount, err := model.ParseMoney(payout.Value, payout.Currency)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
 	newLog := &model.Log{
-		TransactionID:   payout.ID,
+		TransactionID:   payout.PayoutID,
 		TransactionType: yoomodel.PayoutType,
 		Status:          payout.Status,
-		Value:           payout.Amount.Value,
-		Currency:        payout.Amount.Currency,
+		Amount:          amount,
 	}
 
-	err := s.logsSvc.InsertLog(ctx, newLog)
+	err = s.logsSvc.InsertLog(ctx, newLog, source)
 	if err != nil {
 		return err
 	}
 
-	err = s.payoutSubscriber.Subscribe(payout.ID, payout.Status)
+	err = s.repo.InsertPayout(ctx, payout)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = s.ledgerSvc.PostPayout(ctx, payout)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = s.payoutSubscriber.Subscribe(ctx, payout.PayoutID, payout.Status)
 	if err != nil {
 		return err
 	}
@@ -50,13 +81,45 @@ func (s *PayoutsSvc) CreatePayout(ctx context.Context, payout yoomodel.Payout) e
 	return nil
 }
 
-func (s *PayoutsSvc) SchedulePayout(ctx context.Context, payout yoomodel.Payout, scheduler *scheduler.Scheduler) error {
-	const op = "service.payout.SchedulePayout"
+func (s *PayoutsSvc) GetPayoutByID(ctx context.Context, payoutID string) (*model.Payout, error) {
+	const op = "service.payout.GetPayoutByID"
 
-	// TODO get payout date
+	payout, err := s.repo.GetPayoutByID(ctx, payoutID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return payout, nil
+}
+
+// SyncPayout stores the actual payout status received from the provider and updates the logs row when it changed.
+func (s *PayoutsSvc) SyncPayout(ctx context.Context, payout *model.Payout, source model.StatusSource) error {
+	const op = "service.payout.SyncPayout"
+
+	stored, err := s.repo.GetPayoutByID(ctx, payout.PayoutID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if stored.Status != payout.Status {
+		err = s.logsSvc.UpdateLogTransactionStatus(ctx, payout.PayoutID, payout.Status, source)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		err = s.repo.UpdatePayoutStatus(ctx, payout.PayoutID, payout.Status)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		stored.Status = payout.Status
+	}
+
+	// posted even when the status is unchanged, so a redelivered notification retries a failed posting
+	err = s.ledgerSvc.PostPayout(ctx, stored)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
 
-	scheduler.Create("0 22 * * *", func() {
-		fmt.Println("New payout")
-	})
 	return nil
 }
@@ -2,150 +2,191 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"github.com/eclipsemode/go-yookassa-sdk/yookassa"
 	yoomodel "github.com/eclipsemode/go-yookassa-sdk/yookassa/model"
-	"github.com/imperatorofdwelling/payment-svc/internal/storage/redis"
-	"github.com/imperatorofdwelling/payment-svc/pkg/json"
-	"github.com/pkg/errors"
-	"math"
+	"github.com/imperatorofdwelling/payment-svc/internal/config"
+	"github.com/imperatorofdwelling/payment-svc/internal/domain/model"
+	"github.com/imperatorofdwelling/payment-svc/internal/gateway"
+	"github.com/imperatorofdwelling/payment-svc/internal/storage/postgres"
+	"go.uber.org/zap"
+	"sync"
 	"time"
 )
 
 type IPayoutSubscriber interface {
-	Subscribe(payoutID string, status yoomodel.TransactionStatus) error
+	Subscribe(ctx context.Context, payoutID string, status yoomodel.TransactionStatus) error
 }
 
+// PayoutSubscriber polls YooKassa for payouts until their status is final, so the status is recorded even
+// when the notification is lost. Subscribed payouts are queued in Postgres and polled by a fixed number of
+// workers on every replica, a payout in flight during a restart is picked up again once its lease is over.
 type PayoutSubscriber struct {
-	rdbTransaction     redis.ITransactionRepo
-	logsSvc            ILogsSvc
-	yookassaPayoutsSvc *yookassa.PayoutsSvc
+	repo          postgres.IStatusSyncRepo
+	payoutsRepo   postgres.IPayoutsRepo
+	logsSvc       ILogsSvc
+	ledgerSvc     ILedgerSvc
+	payoutGateway gateway.PayoutGateway
+	cfg           config.StatusSync
+	log           *zap.SugaredLogger
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
 }
 
-func NewPayoutSubscriber(rdbTransaction redis.ITransactionRepo, logsSvc ILogsSvc, yookassaPayoutsHdl *yookassa.PayoutsSvc) *PayoutSubscriber {
-	return &PayoutSubscriber{rdbTransaction, logsSvc, yookassaPayoutsHdl}
+func NewPayoutSubscriber(repo postgres.IStatusSyncRepo, payoutsRepo postgres.IPayoutsRepo, logsSvc ILogsSvc, ledgerSvc ILedgerSvc, payoutGateway gateway.PayoutGateway, cfg config.StatusSync, log *zap.SugaredLogger) *PayoutSubscriber {
+	return &PayoutSubscriber{repo: repo, payoutsRepo: payoutsRepo, logsSvc: logsSvc, ledgerSvc: ledgerSvc, payoutGateway: payoutGateway, cfg: cfg, log: log}
 }
 
-func (s *PayoutSubscriber) Subscribe(payoutID string, status yoomodel.TransactionStatus) error {
-	const op = "service.payout.Subscribe"
-	if status == yoomodel.Succeeded || status == yoomodel.Canceled {
-		return nil
-	}
-
-	isExists, err := s.rdbTransaction.IsExists(payoutID)
-	if err != nil {
-		return errors.Wrap(err, op)
-	}
+// Subscribe queues the payout for polling unless its status is already final. Subscribing a queued payout
+// again keeps its schedule.
+func (s *PayoutSubscriber) Subscribe(ctx context.Context, payoutID string, status yoomodel.TransactionStatus) error {
+	const op = "service.payoutsubscriber.Subscribe"
 
-	if isExists {
+	if model.IsFinalStatus(status) {
 		return nil
 	}
 
-	err = s.rdbTransaction.Commit(payoutID, status)
+	err := s.repo.EnqueueStatusSync(ctx, payoutID, yoomodel.PayoutType, time.Now().Add(s.cfg.Backoff))
 	if err != nil {
-		return fmt.Errorf("%s: %v", op, err)
+		return fmt.Errorf("%s: %w", op, err)
 	}
 
-	go func() {
-		err := s.runUpdater(payoutID)
-		if err != nil {
-			fmt.Println(err.Error())
-		}
-	}()
-
 	return nil
 }
 
-func (s *PayoutSubscriber) runUpdater(payoutID string) error {
-	const op = "service.payout.runUpdater"
-
-	ch := make(chan struct{}, 1)
+// Start runs the workers until Stop is called.
+func (s *PayoutSubscriber) Start() {
 	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
+	s.cancel = cancel
 
-	go signaller(ch, ctx)
+	for range max(s.cfg.Workers, 1) {
+		s.wg.Add(1)
 
-	for range ch {
-		var payout yoomodel.Payout
+		go func() {
+			defer s.wg.Done()
+			s.work(ctx)
+		}()
+	}
+}
 
-		res, err := s.yookassaPayoutsSvc.GetPayoutInfo(payoutID)
-		if err != nil {
-			return fmt.Errorf("%s: %s", op, "error getting payout info")
-		}
+// Stop cancels the payouts being polled and waits for the workers to return. The canceled payouts stay
+// claimed and are polled again once their lease is over.
+func (s *PayoutSubscriber) Stop() {
+	if s.cancel == nil {
+		return
+	}
 
-		err = json.Read(res.Body, &payout)
-		if err != nil {
-			return fmt.Errorf("%s: %v", op, json.DecodeBodyError)
-		}
+	s.cancel()
+	s.wg.Wait()
 
-		statusInRedis, err := s.rdbTransaction.GetStatus(payoutID)
-		if err != nil {
-			return fmt.Errorf("%s: %v", op, err)
-		}
+	s.log.Info("Payout subscriber stopped")
+}
 
-		if statusInRedis != payout.Status {
-			err = s.rdbTransaction.UpdateStatus(payoutID, payout.Status)
-			if err != nil {
-				return fmt.Errorf("%s: error updating status of payout in redis", op)
-			}
+// work polls due payouts one by one and waits for PollInterval when none is due.
+func (s *PayoutSubscriber) work(ctx context.Context) {
+	for {
+		item, err := s.repo.ClaimStatusSync(ctx, s.cfg.Lease)
+		if err != nil && ctx.Err() == nil {
+			s.log.Errorf("failed to claim payout to poll: %v", err)
+		}
 
-			err = s.logsSvc.UpdateLogTransactionStatus(ctx, payout.ID, payout.Status)
-			if err != nil {
-				return err
+		if item == nil {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(s.cfg.PollInterval):
 			}
 
-			if payout.Status == yoomodel.Succeeded || payout.Status == yoomodel.Canceled {
-				break
-			}
+			continue
 		}
-	}
 
-	return nil
+		s.process(ctx, item)
+	}
 }
 
-func signaller(ch chan<- struct{}, ctx context.Context) {
-	select {
-	case <-ctx.Done():
-		close(ch)
+// process polls the payout once and removes it from the queue when its status is final. Otherwise it is
+// polled again after a backoff, or given up on GiveUpAfter it was subscribed.
+func (s *PayoutSubscriber) process(ctx context.Context, item *model.StatusSyncItem) {
+	final, err := s.syncPayout(ctx, item.TransactionID)
+	if ctx.Err() != nil {
 		return
-	default:
-		fibArr := getFibArr()
+	}
 
-		for _, timing := range fibArr {
-			sleepTiming := time.Duration(timing) * time.Second
-			sleep(sleepTiming, ctx)
-			ch <- struct{}{}
+	switch {
+	case err == nil && final:
+		err = s.repo.DeleteStatusSync(ctx, item.TransactionID)
+	case time.Since(item.CreatedAt) >= s.cfg.GiveUpAfter:
+		lastError := "status is not final"
+		if err != nil {
+			lastError = err.Error()
 		}
-		close(ch)
-	}
 
-}
+		s.log.Errorf("gave up polling payout %s after %d attempts: %s", item.TransactionID, item.Attempts, lastError)
 
-func sleep(d time.Duration, ctx context.Context) {
-	timer := time.NewTimer(d)
+		err = s.repo.FailStatusSync(ctx, item.TransactionID, lastError)
+	default:
+		var lastError string
+		if err != nil {
+			lastError = err.Error()
+			s.log.Warnf("failed to poll payout %s, attempt %d: %v", item.TransactionID, item.Attempts, err)
+		}
 
-	select {
-	case <-ctx.Done():
-		return
-	case <-timer.C:
-		return
+		err = s.repo.RescheduleStatusSync(ctx, item.TransactionID, time.Now().Add(s.backoff(item.Attempts)), lastError)
+	}
+
+	if err != nil && ctx.Err() == nil {
+		s.log.Errorf("failed to update polled payout %s: %v", item.TransactionID, err)
 	}
 }
 
-func getFibArr() []int {
-	var fibArr = []int{1, 1}
+// syncPayout stores the payout status received from YooKassa and reports whether it is final.
+func (s *PayoutSubscriber) syncPayout(ctx context.Context, payoutID string) (bool, error) {
+	const op = "service.payoutsubscriber.syncPayout"
 
-	var fibSum int
-	maxFibSum := int(math.Round(redis.Expiration.Seconds()))
+	payout, err := s.payoutGateway.GetPayout(ctx, payoutID)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
 
-	index := 2
+	stored, err := s.payoutsRepo.GetPayoutByID(ctx, payoutID)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
 
-	for {
-		nextFibNum := fibArr[index-1] + fibArr[index-2]
-		if fibSum+nextFibNum >= maxFibSum {
-			return fibArr
+	if stored.Status != payout.Status {
+		err = s.logsSvc.UpdateLogTransactionStatus(ctx, payoutID, payout.Status, model.PollerSource)
+		if errors.Is(err, ErrIllegalStatusTransition) {
+			// the status was already moved further, e.g. by a webhook, nothing left to poll
+			return true, nil
 		}
-		fibArr = append(fibArr, nextFibNum)
-		index++
+		if err != nil {
+			return false, fmt.Errorf("%s: %w", op, err)
+		}
+
+		err = s.payoutsRepo.UpdatePayoutStatus(ctx, payoutID, payout.Status)
+		if err != nil {
+			return false, fmt.Errorf("%s: %w", op, err)
+		}
+
+		stored.Status = payout.Status
+	}
+
+	// posted even when the status is unchanged, so a posting that failed is retried on the next attempt
+	err = s.ledgerSvc.PostPayout(ctx, stored)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
 	}
+
+	return model.IsFinalStatus(payout.Status), nil
+}
+
+// backoff returns the pause before the next attempt: Backoff doubled for every attempt made, up to MaxBackoff.
+func (s *PayoutSubscriber) backoff(attempts int) time.Duration {
+	delay := s.cfg.Backoff
+	for i := 1; i < attempts && delay < s.cfg.MaxBackoff; i++ {
+		delay *= 2
+	}
+
+	return min(delay, s.cfg.MaxBackoff)
 }
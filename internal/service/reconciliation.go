@@ -0,0 +1,269 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	yoomodel "github.com/eclipsemode/go-yookassa-sdk/yookassa/model"
+	"github.com/imperatorofdwelling/payment-svc/internal/domain/model"
+	"github.com/imperatorofdwelling/payment-svc/internal/gateway"
+	"github.com/imperatorofdwelling/payment-svc/internal/storage/postgres"
+	"go.uber.org/zap"
+	"sort"
+	"time"
+)
+
+type IReconciliationSvc interface {
+	Reconcile(ctx context.Context, day time.Time) (*model.ReconciliationReport, error)
+	GetReportByDate(ctx context.Context, day time.Time) (*model.ReconciliationReport, error)
+}
+
+type ReconciliationSvc struct {
+	repo           postgres.IReconciliationRepo
+	logsSvc        ILogsSvc
+	paymentGateway gateway.PaymentGateway
+	payoutGateway  gateway.PayoutGateway
+	log            *zap.SugaredLogger
+}
+
+func NewReconciliationService(repo postgres.IReconciliationRepo, logsSvc ILogsSvc, paymentGateway gateway.PaymentGateway, payoutGateway gateway.PayoutGateway, log *zap.SugaredLogger) *ReconciliationSvc {
+	return &ReconciliationSvc{repo, logsSvc, paymentGateway, payoutGateway, log}
+}
+
+// upstreamTx is the part of a YooKassa payment or payout that is compared with the logs row.
+type upstreamTx struct {
+	ID       string
+	Status   yoomodel.TransactionStatus
+	Value    string
+	Currency yoomodel.Currency
+}
+
+// Reconcile compares payments and payouts created during the UTC day with YooKassa and stores the report,
+// replacing the previous report for that day. Transactions found on one side only are looked up on the
+// other side by id before being reported, so creation times around midnight do not produce false positives.
+func (s *ReconciliationSvc) Reconcile(ctx context.Context, day time.Time) (*model.ReconciliationReport, error) {
+	const op = "service.reconciliation.Reconcile"
+
+	from := truncateDay(day)
+	to := from.AddDate(0, 0, 1)
+
+	report := &model.ReconciliationReport{Date: from, Discrepancies: make([]model.Discrepancy, 0)}
+
+	for _, txType := range []yoomodel.TransactionType{yoomodel.PaymentType, yoomodel.PayoutType} {
+		checked, discrepancies, err := s.reconcileType(ctx, txType, from, to)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		if txType == yoomodel.PaymentType {
+			report.PaymentsChecked = checked
+		} else {
+			report.PayoutsChecked = checked
+		}
+
+		report.Discrepancies = append(report.Discrepancies, discrepancies...)
+	}
+
+	sort.Slice(report.Discrepancies, func(i, j int) bool {
+		return report.Discrepancies[i].TransactionID < report.Discrepancies[j].TransactionID
+	})
+
+	err := s.repo.SaveReport(ctx, report)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.log.Infof("reconciliation for %s: %d payments, %d payouts checked, %d discrepancies",
+		from.Format(time.DateOnly), report.PaymentsChecked, report.PayoutsChecked, len(report.Discrepancies))
+
+	return report, nil
+}
+
+func (s *ReconciliationSvc) GetReportByDate(ctx context.Context, day time.Time) (*model.ReconciliationReport, error) {
+	const op = "service.reconciliation.GetReportByDate"
+
+	report, err := s.repo.GetReportByDate(ctx, truncateDay(day))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return report, nil
+}
+
+func (s *ReconciliationSvc) reconcileType(ctx context.Context, txType yoomodel.TransactionType, from, to time.Time) (int, []model.Discrepancy, error) {
+	upstream, err := s.listUpstream(ctx, txType, from, to)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	local := make(map[string]model.Log)
+
+	err = s.logsSvc.ExportLogs(ctx, model.LogFilter{
+		TransactionType: txType,
+		CreatedFrom:     &from,
+		CreatedTo:       &to,
+		Order:           model.SortAsc,
+	}, func(l model.Log) error {
+		local[l.TransactionID] = l
+		return nil
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+
+	var discrepancies []model.Discrepancy
+
+	checked := len(upstream)
+
+	for id, u := range upstream {
+		l, ok := local[id]
+		if !ok {
+			found, err := s.logsSvc.GetLogByTransactionID(ctx, id)
+			if errors.Is(err, postgres.ErrLogNotFound) {
+				discrepancies = append(discrepancies, newDiscrepancy(txType, model.MissingLocally, nil, &u))
+				continue
+			}
+			if err != nil {
+				return 0, nil, err
+			}
+
+			l = *found
+		}
+
+		discrepancies = append(discrepancies, compareTx(txType, l, u)...)
+	}
+
+	for id, l := range local {
+		if _, ok := upstream[id]; ok {
+			continue
+		}
+
+		checked++
+
+		u, err := s.getUpstream(ctx, txType, id)
+		if err != nil {
+			return 0, nil, err
+		}
+
+		if u == nil {
+			discrepancies = append(discrepancies, newDiscrepancy(txType, model.MissingUpstream, &l, nil))
+			continue
+		}
+
+		discrepancies = append(discrepancies, compareTx(txType, l, *u)...)
+	}
+
+	return checked, discrepancies, nil
+}
+
+// listUpstream fetches every payment or payout YooKassa has for [from, to).
+func (s *ReconciliationSvc) listUpstream(ctx context.Context, txType yoomodel.TransactionType, from, to time.Time) (map[string]upstreamTx, error) {
+	result := make(map[string]upstreamTx)
+
+	if txType == yoomodel.PaymentType {
+		payments, err := s.paymentGateway.ListPayments(ctx, from, to)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, p := range payments {
+			result[p.PaymentID] = paymentToUpstream(p)
+		}
+
+		return result, nil
+	}
+
+	payouts, err := s.payoutGateway.ListPayouts(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, p := range payouts {
+		result[p.PayoutID] = payoutToUpstream(p)
+	}
+
+	return result, nil
+}
+
+// getUpstream fetches a single transaction from YooKassa. It returns nil when YooKassa does not know the id.
+func (s *ReconciliationSvc) getUpstream(ctx context.Context, txType yoomodel.TransactionType, id string) (*upstreamTx, error) {
+	var u upstreamTx
+
+	if txType == yoomodel.PaymentType {
+		p, err := s.paymentGateway.GetPayment(ctx, id)
+		if errors.Is(err, gateway.ErrNotFound) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		u = paymentToUpstream(*p)
+	} else {
+		p, err := s.payoutGateway.GetPayout(ctx, id)
+		if errors.Is(err, gateway.ErrNotFound) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		u = payoutToUpstream(*p)
+	}
+
+	return &u, nil
+}
+
+func paymentToUpstream(p model.Payment) upstreamTx {
+	return upstreamTx{ID: p.PaymentID, Status: p.Status, Value: p.Amount.Decimal(), Currency: p.Amount.Currency}
+}
+
+func payoutToUpstream(p model.Payout) upstreamTx {
+	return upstreamTx{ID: p.PayoutID, Status: p.Status, Value: p.Value, Currency: p.Currency}
+}
+
+func compareTx(txType yoomodel.TransactionType, l model.Log, u upstreamTx) []model.Discrepancy {
+	var discrepancies []model.Discrepancy
+
+	if l.Status != u.Status {
+		discrepancies = append(discrepancies, newDiscrepancy(txType, model.StatusMismatch, &l, &u))
+	}
+
+	var cmp int
+
+	upstream, err := model.ParseMoney(u.Value, u.Currency)
+	if err == nil {
+		cmp, err = l.Amount.Cmp(upstream)
+	}
+
+	if err != nil || cmp != 0 {
+		discrepancies = append(discrepancies, newDiscrepancy(txType, model.AmountMismatch, &l, &u))
+	}
+
+	return discrepancies
+}
+
+func newDiscrepancy(txType yoomodel.TransactionType, kind model.DiscrepancyKind, l *model.Log, u *upstreamTx) model.Discrepancy {
+	d := model.Discrepancy{TransactionType: txType, Kind: kind}
+
+	if l != nil {
+		d.TransactionID = l.TransactionID
+		d.LocalStatus = l.Status
+		d.LocalValue = l.Amount.Decimal()
+		d.LocalCurrency = l.Amount.Currency
+	}
+
+	if u != nil {
+		d.TransactionID = u.ID
+		d.UpstreamStatus = u.Status
+		d.UpstreamValue = u.Value
+		d.UpstreamCurrency = u.Currency
+	}
+
+	return d
+}
+
+func truncateDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
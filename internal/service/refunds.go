@@ -0,0 +1,207 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/eclipsemode/go-yookassa-sdk/yookassa/model"
+	"github.com/imperatorofdwelling/payment-svc/internal/domain/model"
+	"github.com/imperatorofdwelling/payment-svc/internal/storage/postgres"
+	"go.uber.org/zap"
+	"time"
+)
+
+// refundLockTimeout bounds how long a refund holds the lock of its payment. It covers a provider call with a
+// retry, a refund that outlasts it is still limited by YooKassa, which rejects refunds above the payment amount.
+const refundLockTimeout = 20 * time.Second
+
+type IRefundsSvc interface {
+	LockRefunds(ctx context.Context, paymentID string) (release func(), err error)
+	CheckRefund(ctx context.Context, paymentID string, amount model.Money) error
+	CreateRefund(ctx context.Context, refund *model.Refund, idempotenceKey string, source model.StatusSource) (*model.Refund, error)
+	GetRefundsByPaymentID(ctx context.Context, paymentID string) ([]model.Refund, error)
+	SyncRefund(ctx context.Context, refund *model.Refund, source model.StatusSource) (*model.Refund, error)
+}
+
+type RefundsSvc struct {
+	repo        postgres.IRefundsRepo
+	paymentRepo postgres.IPaymentRepo
+	logsSvc     ILogsSvc
+	ledgerSvc   ILedgerSvc
+	log         *zap.SugaredLogger
+}
+
+func NewRefundsService(repo postgres.IRefundsRepo, paymentRepo postgres.IPaymentRepo, logsSvc ILogsSvc, ledgerSvc ILedgerSvc, log *zap.SugaredLogger) *RefundsSvc {
+	return &RefundsSvc{repo, paymentRepo, logsSvc, ledgerSvc, log}
+}
+
+// LockRefunds serializes refunds of the payment. It must be held from CheckRefund until the refund created
+// at the provider is stored, otherwise concurrent refunds can pass the check together and exceed the payment.
+// The lock holds a database connection, so it is released after refundLockTimeout even if the provider has
+// not answered yet, and a provider outage can not take up the whole pool.
+func (s *RefundsSvc) LockRefunds(ctx context.Context, paymentID string) (func(), error) {
+	const op = "service.refunds.LockRefunds"
+
+	ctx, cancel := context.WithTimeout(ctx, refundLockTimeout)
+
+	unlock, err := s.paymentRepo.LockPayment(ctx, paymentID)
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return func() {
+		unlock()
+		cancel()
+	}, nil
+}
+
+// CheckRefund verifies that the payment was captured and that the cumulative refunds,
+// including the requested one, do not exceed the captured amount.
+func (s *RefundsSvc) CheckRefund(ctx context.Context, paymentID string, amount model.Money) error {
+	const op = "service.refunds.CheckRefund"
+
+	payment, err := s.paymentRepo.GetPaymentByID(ctx, paymentID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if payment.Status != yoomodel.Succeeded {
+		return fmt.Errorf("%s: %w", op, ErrPaymentNotRefundable)
+	}
+
+	if amount.Currency != payment.Amount.Currency {
+		return fmt.Errorf("%s: %w", op, ErrCurrencyMismatch)
+	}
+
+	refunded, err := s.repo.GetRefundedAmount(ctx, paymentID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	total, err := model.ParseMoney(refunded, payment.Amount.Currency)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if amount.IsZero() || amount.IsNegative() {
+		return fmt.Errorf("%s: %w: %s", op, ErrInvalidAmount, amount)
+	}
+
+	total, err = total.Add(amount)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	cmp, err := total.Cmp(payment.Amount)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if cmp > 0 {
+		return fmt.Errorf("%s: %w", op, ErrRefundAmountExceeded)
+	}
+
+	return nil
+}
+
+// CreateRefund stores the refund created at the provider.
+func (s *RefundsSvc) CreateRefund(ctx context.Context, refund *model.Refund, idempotenceKey string, source model.StatusSource) (*model.Refund, error) {
+	const op = "service.refunds.CreateRefund"
+
+	amount, err := model.ParseMoney(refund.Value, refund.Currency)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	newLog := &model.Log{
+		TransactionID:   refund.RefundID,
+		TransactionType: yoomodel.RefundType,
+		Status:          refund.Status,
+		Amount:          amount,
+	}
+
+	err = s.logsSvc.InsertLog(ctx, newLog, source)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	refund.IdempotenceKey = idempotenceKey
+
+	err = s.repo.InsertRefund(ctx, refund)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	stored, err := s.repo.GetRefundByID(ctx, refund.RefundID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = s.postRefund(ctx, stored)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return stored, nil
+}
+
+func (s *RefundsSvc) GetRefundsByPaymentID(ctx context.Context, paymentID string) ([]model.Refund, error) {
+	const op = "service.refunds.GetRefundsByPaymentID"
+
+	_, err := s.paymentRepo.GetPaymentByID(ctx, paymentID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	refunds, err := s.repo.GetRefundsByPaymentID(ctx, paymentID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return refunds, nil
+}
+
+// SyncRefund stores the actual refund status received from the provider and updates the logs row when it changed.
+func (s *RefundsSvc) SyncRefund(ctx context.Context, refund *model.Refund, source model.StatusSource) (*model.Refund, error) {
+	const op = "service.refunds.SyncRefund"
+
+	stored, err := s.repo.GetRefundByID(ctx, refund.RefundID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if stored.Status != refund.Status {
+		err = s.logsSvc.UpdateLogTransactionStatus(ctx, refund.RefundID, refund.Status, source)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		err = s.repo.UpdateRefundStatus(ctx, refund.RefundID, refund.Status)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		stored.Status = refund.Status
+	}
+
+	// posted even when the status is unchanged, so a redelivered notification retries a failed posting
+	err = s.postRefund(ctx, stored)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return stored, nil
+}
+
+func (s *RefundsSvc) postRefund(ctx context.Context, refund *model.Refund) error {
+	if refund.Status != yoomodel.Succeeded {
+		return nil
+	}
+
+	payment, err := s.paymentRepo.GetPaymentByID(ctx, refund.PaymentID)
+	if err != nil {
+		return err
+	}
+
+	return s.ledgerSvc.PostRefund(ctx, refund, payment)
+}
@@ -0,0 +1,293 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"expvar"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/imperatorofdwelling/payment-svc/internal/domain/model"
+	"github.com/imperatorofdwelling/payment-svc/internal/gateway"
+	"github.com/imperatorofdwelling/payment-svc/internal/lib/scheduler"
+	"github.com/imperatorofdwelling/payment-svc/internal/storage/postgres"
+	"go.uber.org/zap"
+	"time"
+)
+
+// ScheduledPayoutJob is the scheduler job kind executing scheduled payouts.
+const ScheduledPayoutJob = "scheduled_payout"
+
+// An execution the provider did not answer definitively is repeated after scheduledPayoutRetryBackoff,
+// doubled with every attempt up to scheduledPayoutMaxRetryBackoff.
+const (
+	scheduledPayoutRetryBackoff    = time.Minute
+	scheduledPayoutMaxRetryBackoff = time.Hour
+)
+
+// scheduledPayoutRetryWindow bounds how long after its first execution a payout is executed again. YooKassa
+// keeps an idempotence key for 24 hours, a retry after that could pay a second time.
+const scheduledPayoutRetryWindow = 23 * time.Hour
+
+// scheduledPayoutMetrics is published at /debug/vars. Unconfirmed counts payouts that need a manual check.
+var scheduledPayoutMetrics = expvar.NewMap("scheduled_payouts")
+
+type IScheduledPayoutsSvc interface {
+	SchedulePayout(ctx context.Context, payout model.PayoutRequest, executeAt time.Time) (*model.ScheduledPayout, error)
+	GetScheduledPayoutByID(ctx context.Context, id uuid.UUID) (*model.ScheduledPayout, error)
+	GetScheduledPayouts(ctx context.Context, status model.ScheduledPayoutStatus) ([]model.ScheduledPayout, error)
+	CancelScheduledPayout(ctx context.Context, id uuid.UUID) error
+}
+
+type ScheduledPayoutsSvc struct {
+	repo          postgres.IScheduledPayoutsRepo
+	payoutsSvc    IPayoutsSvc
+	payoutGateway gateway.PayoutGateway
+	scheduler     *scheduler.Scheduler
+	log           *zap.SugaredLogger
+}
+
+func NewScheduledPayoutsService(repo postgres.IScheduledPayoutsRepo, payoutsSvc IPayoutsSvc, payoutGateway gateway.PayoutGateway, scheduler *scheduler.Scheduler, log *zap.SugaredLogger) *ScheduledPayoutsSvc {
+	s := &ScheduledPayoutsSvc{repo, payoutsSvc, payoutGateway, scheduler, log}
+
+	scheduler.RegisterHandler(ScheduledPayoutJob, s.execute)
+
+	return s
+}
+
+type scheduledPayoutPayload struct {
+	ID      uuid.UUID `json:"id"`
+	Attempt int       `json:"attempt,omitempty"`
+}
+
+// SchedulePayout stores the payout request and schedules its execution.
+func (s *ScheduledPayoutsSvc) SchedulePayout(ctx context.Context, payout model.PayoutRequest, executeAt time.Time) (*model.ScheduledPayout, error) {
+	const op = "service.scheduledpayouts.SchedulePayout"
+
+	if !executeAt.After(time.Now()) {
+		return nil, fmt.Errorf("%s: %w", op, ErrExecuteAtInPast)
+	}
+
+	scheduled := &model.ScheduledPayout{
+		Payout:         payout,
+		IdempotenceKey: uuid.NewString(),
+		ExecuteAt:      executeAt,
+	}
+
+	err := s.repo.InsertScheduledPayout(ctx, scheduled)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	job, err := s.scheduler.ScheduleJob(ctx, ScheduledPayoutJob, scheduledPayoutPayload{ID: scheduled.ID}, executeAt)
+	if err != nil {
+		s.markFailed(ctx, scheduled.ID, model.ScheduledPayoutPending, err)
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// the job already executes the payout, failing here would make the client schedule it a second time
+	err = s.repo.SetScheduledPayoutJob(ctx, scheduled.ID, job.ID)
+	if err != nil {
+		s.log.Errorf("%s: failed to set job %s of scheduled payout %s: %v", op, job.ID, scheduled.ID, err)
+	} else {
+		scheduled.JobID = &job.ID
+	}
+
+	return scheduled, nil
+}
+
+func (s *ScheduledPayoutsSvc) GetScheduledPayoutByID(ctx context.Context, id uuid.UUID) (*model.ScheduledPayout, error) {
+	const op = "service.scheduledpayouts.GetScheduledPayoutByID"
+
+	scheduled, err := s.repo.GetScheduledPayoutByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return scheduled, nil
+}
+
+func (s *ScheduledPayoutsSvc) GetScheduledPayouts(ctx context.Context, status model.ScheduledPayoutStatus) ([]model.ScheduledPayout, error) {
+	const op = "service.scheduledpayouts.GetScheduledPayouts"
+
+	payouts, err := s.repo.GetScheduledPayouts(ctx, status)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return payouts, nil
+}
+
+// CancelScheduledPayout cancels a payout that has not started executing yet.
+func (s *ScheduledPayoutsSvc) CancelScheduledPayout(ctx context.Context, id uuid.UUID) error {
+	const op = "service.scheduledpayouts.CancelScheduledPayout"
+
+	scheduled, err := s.repo.GetScheduledPayoutByID(ctx, id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	canceled, err := s.repo.UpdateScheduledPayoutStatus(ctx, id, model.ScheduledPayoutPending, model.ScheduledPayoutCanceled, "", "")
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if !canceled {
+		return fmt.Errorf("%s: %w", op, ErrScheduledPayoutNotCancelable)
+	}
+
+	if scheduled.JobID != nil {
+		if _, err := s.scheduler.CancelJob(ctx, *scheduled.JobID); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return nil
+}
+
+// execute is the scheduler job handler. Moving the payout to processing first guarantees that a payout
+// canceled in the meantime is not executed. A payout left in processing by a crash is executed again with
+// the same idempotence key, so YooKassa returns the payout it already made instead of paying twice.
+// Only a rejection by the provider fails the payout, any other error may hide a payout that was made,
+// so the payout stays processing and is executed again later, as long as the idempotence key is honoured.
+func (s *ScheduledPayoutsSvc) execute(ctx context.Context, payload []byte) error {
+	const op = "service.scheduledpayouts.execute"
+
+	var p scheduledPayoutPayload
+
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	scheduled, err := s.repo.GetScheduledPayoutByID(ctx, p.ID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	switch scheduled.Status {
+	case model.ScheduledPayoutPending:
+		started, err := s.repo.StartScheduledPayout(ctx, scheduled.ID)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		if !started {
+			s.log.Infof("%s: scheduled payout %s was canceled before execution", op, scheduled.ID)
+			return nil
+		}
+
+		now := time.Now()
+		scheduled.StartedAt = &now
+	case model.ScheduledPayoutProcessing:
+		if !time.Now().Before(retryDeadline(scheduled)) {
+			cause := scheduled.LastError
+			if cause == "" {
+				cause = "execution was interrupted"
+			}
+
+			s.markUnconfirmed(ctx, scheduled.ID, errors.New(cause))
+			return nil
+		}
+
+		s.log.Warnf("%s: resuming scheduled payout %s", op, scheduled.ID)
+	default:
+		s.log.Infof("%s: scheduled payout %s is %s, skipping", op, scheduled.ID, scheduled.Status)
+		return nil
+	}
+
+	payoutID, err := s.makePayout(ctx, scheduled)
+	if errors.Is(err, gateway.ErrRejected) {
+		s.markFailed(ctx, scheduled.ID, model.ScheduledPayoutProcessing, err)
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err != nil {
+		s.retry(scheduled, p.Attempt+1, err)
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = s.repo.UpdateScheduledPayoutStatus(ctx, scheduled.ID, model.ScheduledPayoutProcessing, model.ScheduledPayoutDone, payoutID, "")
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *ScheduledPayoutsSvc) makePayout(ctx context.Context, scheduled *model.ScheduledPayout) (string, error) {
+	created, err := s.payoutGateway.CreatePayout(ctx, &scheduled.Payout, scheduled.IdempotenceKey)
+	if err != nil {
+		return "", err
+	}
+
+	// rows are inserted idempotently, so a resumed execution only adds what is missing
+	err = s.payoutsSvc.CreatePayout(ctx, created, model.SchedulerSource)
+	if err != nil {
+		return "", err
+	}
+
+	return created.PayoutID, nil
+}
+
+// retry schedules another execution of a payout left in processing. A payout that would be executed after
+// its retry window is left unconfirmed instead. It does not use the context of the failed execution, so the
+// payout is not stuck in processing when that context is canceled.
+func (s *ScheduledPayoutsSvc) retry(scheduled *model.ScheduledPayout, attempt int, cause error) {
+	ctx := context.Background()
+	id := scheduled.ID
+
+	delay := scheduledPayoutRetryBackoff
+	for i := 1; i < attempt && delay < scheduledPayoutMaxRetryBackoff; i++ {
+		delay *= 2
+	}
+
+	delay = min(delay, scheduledPayoutMaxRetryBackoff)
+
+	if time.Now().Add(delay).After(retryDeadline(scheduled)) {
+		s.markUnconfirmed(ctx, id, cause)
+		return
+	}
+
+	if _, err := s.repo.UpdateScheduledPayoutStatus(ctx, id, model.ScheduledPayoutProcessing, model.ScheduledPayoutProcessing, "", cause.Error()); err != nil {
+		s.log.Errorf("failed to record error of scheduled payout %s: %v", id, err)
+	}
+
+	job, err := s.scheduler.ScheduleJob(ctx, ScheduledPayoutJob, scheduledPayoutPayload{ID: id, Attempt: attempt}, time.Now().Add(delay))
+	if err != nil {
+		s.log.Errorf("failed to reschedule scheduled payout %s, it stays processing: %v", id, err)
+		return
+	}
+
+	if err := s.repo.SetScheduledPayoutJob(ctx, id, job.ID); err != nil {
+		s.log.Errorf("failed to set job of scheduled payout %s: %v", id, err)
+	}
+
+	s.log.Warnf("scheduled payout %s failed, attempt %d in %s: %v", id, attempt+1, delay, cause)
+}
+
+// markUnconfirmed stops executing a payout whose idempotence key is about to expire. Whether it was paid
+// is unknown, so it is not failed but left for a manual check against YooKassa.
+func (s *ScheduledPayoutsSvc) markUnconfirmed(ctx context.Context, id uuid.UUID, cause error) {
+	if _, err := s.repo.UpdateScheduledPayoutStatus(ctx, id, model.ScheduledPayoutProcessing, model.ScheduledPayoutUnconfirmed, "", cause.Error()); err != nil {
+		s.log.Errorf("failed to mark scheduled payout %s as unconfirmed: %v", id, err)
+		return
+	}
+
+	scheduledPayoutMetrics.Add("unconfirmed", 1)
+	s.log.Errorf("scheduled payout %s was not confirmed by the provider within %s and needs a manual check: %v", id, scheduledPayoutRetryWindow, cause)
+}
+
+func (s *ScheduledPayoutsSvc) markFailed(ctx context.Context, id uuid.UUID, from model.ScheduledPayoutStatus, cause error) {
+	if _, err := s.repo.UpdateScheduledPayoutStatus(ctx, id, from, model.ScheduledPayoutFailed, "", cause.Error()); err != nil {
+		s.log.Errorf("failed to mark scheduled payout %s as failed: %v", id, err)
+	}
+}
+
+// retryDeadline is the moment after which the payout must not be executed again with its idempotence key.
+func retryDeadline(scheduled *model.ScheduledPayout) time.Time {
+	started := scheduled.ExecuteAt
+	if scheduled.StartedAt != nil {
+		started = *scheduled.StartedAt
+	}
+
+	return started.Add(scheduledPayoutRetryWindow)
+}
@@ -3,6 +3,7 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"github.com/google/uuid"
 	"github.com/imperatorofdwelling/payment-svc/internal/domain/model"
@@ -10,11 +11,15 @@ import (
 	"time"
 )
 
+var ErrCardNotFound = errors.New("card not found")
+
 type ICardsRepo interface {
 	CreateCard(context.Context, model.Card) error
 	UpdateCard(context.Context, model.Card) error
 	CardSynonymIsExists(ctx context.Context, synonym string) (bool, error)
 	CheckCardExistsByID(ctx context.Context, cardID uuid.UUID) (bool, error)
+	GetCardByPayoutToken(ctx context.Context, token string) (*model.Card, error)
+	GetCardByID(ctx context.Context, cardID uuid.UUID) (*model.Card, error)
 	DeleteCardByID(context.Context, uuid.UUID) error
 }
 
@@ -109,6 +114,52 @@ func (r *CardsRepo) CheckCardExistsByID(ctx context.Context, cardID uuid.UUID) (
 	return exists, nil
 }
 
+func (r *CardsRepo) GetCardByPayoutToken(ctx context.Context, token string) (*model.Card, error) {
+	const op = "repo.postgres.card.GetCardByPayoutToken"
+
+	stmt, err := r.db.PrepareContext(ctx, "SELECT id, user_id, COALESCE(issuer_name, ''), COALESCE(issuer_country, ''), payout_token, first6, last4, card_type, created_at, updated_at FROM bank_cards WHERE payout_token = $1")
+	if err != nil {
+		return nil, fmt.Errorf("%v: %v", op, err)
+	}
+
+	defer stmt.Close()
+
+	var card model.Card
+
+	err = stmt.QueryRowContext(ctx, token).Scan(&card.ID, &card.UserId, &card.IssuerName, &card.IssuerCountry, &card.PayoutToken, &card.First6, &card.Last4, &card.CardType, &card.CreatedAt, &card.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%v: %w", op, ErrCardNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%v: %v", op, err)
+	}
+
+	return &card, nil
+}
+
+func (r *CardsRepo) GetCardByID(ctx context.Context, cardID uuid.UUID) (*model.Card, error) {
+	const op = "repo.postgres.card.GetCardByID"
+
+	stmt, err := r.db.PrepareContext(ctx, "SELECT id, user_id, COALESCE(issuer_name, ''), COALESCE(issuer_country, ''), payout_token, first6, last4, card_type, created_at, updated_at FROM bank_cards WHERE id = $1")
+	if err != nil {
+		return nil, fmt.Errorf("%v: %v", op, err)
+	}
+
+	defer stmt.Close()
+
+	var card model.Card
+
+	err = stmt.QueryRowContext(ctx, cardID).Scan(&card.ID, &card.UserId, &card.IssuerName, &card.IssuerCountry, &card.PayoutToken, &card.First6, &card.Last4, &card.CardType, &card.CreatedAt, &card.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%v: %w", op, ErrCardNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%v: %v", op, err)
+	}
+
+	return &card, nil
+}
+
 func (r *CardsRepo) DeleteCardByID(ctx context.Context, cardID uuid.UUID) error {
 	const op = "repo.postgres.card.DeleteCardByID"
 
@@ -0,0 +1,133 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/imperatorofdwelling/payment-svc/internal/domain/model"
+	"go.uber.org/zap"
+	"time"
+)
+
+var ErrJobNotFound = errors.New("scheduled job not found")
+
+type IJobsRepo interface {
+	InsertJob(ctx context.Context, job *model.ScheduledJob) error
+	GetJobByID(ctx context.Context, id uuid.UUID) (*model.ScheduledJob, error)
+	GetPendingJobs(ctx context.Context) ([]model.ScheduledJob, error)
+	UpdateJobStatus(ctx context.Context, id uuid.UUID, from, to model.JobStatus, lastError string) (bool, error)
+}
+
+const jobColumns = `id, kind, payload, run_at, status, COALESCE(last_error, ''), created_at, updated_at`
+
+type JobsRepo struct {
+	db  *sql.DB
+	log *zap.SugaredLogger
+}
+
+func NewJobsRepo(db *sql.DB, log *zap.SugaredLogger) *JobsRepo {
+	return &JobsRepo{db, log}
+}
+
+func (r *JobsRepo) InsertJob(ctx context.Context, job *model.ScheduledJob) error {
+	const op = "repo.postgres.jobs.InsertJob"
+
+	payload := []byte(job.Payload)
+	if len(payload) == 0 {
+		payload = []byte("{}")
+	}
+
+	err := r.db.QueryRowContext(ctx, `INSERT INTO scheduled_jobs(kind, payload, run_at, status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $5) RETURNING id, created_at, updated_at`,
+		job.Kind, payload, job.RunAt, model.JobPending, time.Now()).Scan(&job.ID, &job.CreatedAt, &job.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	job.Status = model.JobPending
+
+	return nil
+}
+
+func (r *JobsRepo) GetJobByID(ctx context.Context, id uuid.UUID) (*model.ScheduledJob, error) {
+	const op = "repo.postgres.jobs.GetJobByID"
+
+	job, err := scanJob(r.db.QueryRowContext(ctx, `SELECT `+jobColumns+` FROM scheduled_jobs WHERE id = $1`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%s: %w", op, ErrJobNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return job, nil
+}
+
+func (r *JobsRepo) GetPendingJobs(ctx context.Context) ([]model.ScheduledJob, error) {
+	const op = "repo.postgres.jobs.GetPendingJobs"
+
+	rows, err := r.db.QueryContext(ctx, `SELECT `+jobColumns+` FROM scheduled_jobs WHERE status = $1 ORDER BY run_at`, model.JobPending)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer rows.Close()
+
+	jobs := make([]model.ScheduledJob, 0)
+
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		jobs = append(jobs, *job)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return jobs, nil
+}
+
+// UpdateJobStatus moves the job from one status to another. It returns false when the job is not in
+// the from status anymore, e.g. it was canceled while running.
+func (r *JobsRepo) UpdateJobStatus(ctx context.Context, id uuid.UUID, from, to model.JobStatus, lastError string) (bool, error) {
+	const op = "repo.postgres.jobs.UpdateJobStatus"
+
+	res, err := r.db.ExecContext(ctx, `UPDATE scheduled_jobs SET status = $1, last_error = NULLIF($2, ''), updated_at = $3 WHERE id = $4 AND status = $5`,
+		to, lastError, time.Now(), id, from)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	updated, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return updated == 1, nil
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanJob(row rowScanner) (*model.ScheduledJob, error) {
+	var (
+		job     model.ScheduledJob
+		payload []byte
+	)
+
+	err := row.Scan(&job.ID, &job.Kind, &payload, &job.RunAt, &job.Status, &job.LastError, &job.CreatedAt, &job.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	job.Payload = payload
+
+	return &job, nil
+}
@@ -0,0 +1,211 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	yoomodel "github.com/eclipsemode/go-yookassa-sdk/yookassa/model"
+	"github.com/google/uuid"
+	"github.com/imperatorofdwelling/payment-svc/internal/domain/model"
+	"go.uber.org/zap"
+	"strings"
+	"time"
+)
+
+type ILedgerRepo interface {
+	InsertPosting(ctx context.Context, posting *model.Posting) (bool, error)
+	GetBalances(ctx context.Context, userID uuid.UUID) ([]model.Balance, error)
+	GetBalance(ctx context.Context, userID uuid.UUID, currency yoomodel.Currency) (string, error)
+	GetBalanceChanges(ctx context.Context, filter model.LedgerFilter) ([]model.BalanceChange, error)
+}
+
+type LedgerRepo struct {
+	db  *sql.DB
+	log *zap.SugaredLogger
+}
+
+func NewLedgerRepo(db *sql.DB, log *zap.SugaredLogger) *LedgerRepo {
+	return &LedgerRepo{db, log}
+}
+
+// InsertPosting writes the posting entries and applies them to the account balances in one transaction.
+// Accounts are created on first use. It reports false without writing anything when a posting of the
+// same kind for the same reference already exists, so repeated events are posted once.
+func (r *LedgerRepo) InsertPosting(ctx context.Context, posting *model.Posting) (bool, error) {
+	const op = "repo.postgres.ledger.InsertPosting"
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer tx.Rollback()
+
+	now := time.Now()
+
+	var postingID uuid.UUID
+
+	err = tx.QueryRowContext(ctx, `INSERT INTO ledger_postings(kind, reference_id, currency, created_at) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (kind, reference_id) DO NOTHING RETURNING id`,
+		posting.Kind, posting.ReferenceID, posting.Currency, now).Scan(&postingID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// the upsert locks the account row until commit, so concurrent postings to one account are serialized
+	accountStmt, err := tx.PrepareContext(ctx, `INSERT INTO accounts(type, user_id, currency, balance, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $5)
+		ON CONFLICT ON CONSTRAINT accounts_type_user_id_currency_key
+		DO UPDATE SET balance = accounts.balance + EXCLUDED.balance, updated_at = EXCLUDED.updated_at
+		RETURNING id`)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer accountStmt.Close()
+
+	entryStmt, err := tx.PrepareContext(ctx, `INSERT INTO ledger_entries(posting_id, account_id, value, created_at) VALUES ($1, $2, $3, $4)`)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer entryStmt.Close()
+
+	for _, e := range posting.Entries {
+		var accountID uuid.UUID
+
+		err = accountStmt.QueryRowContext(ctx, e.Account, e.UserID, posting.Currency, e.Value, now).Scan(&accountID)
+		if err != nil {
+			return false, fmt.Errorf("%s: %w", op, err)
+		}
+
+		_, err = entryStmt.ExecContext(ctx, postingID, accountID, e.Value, now)
+		if err != nil {
+			return false, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return true, nil
+}
+
+func (r *LedgerRepo) GetBalances(ctx context.Context, userID uuid.UUID) ([]model.Balance, error) {
+	const op = "repo.postgres.ledger.GetBalances"
+
+	rows, err := r.db.QueryContext(ctx, `SELECT currency, balance, updated_at FROM accounts
+		WHERE type = $1 AND user_id = $2 ORDER BY currency`, model.UserAccount, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer rows.Close()
+
+	balances := make([]model.Balance, 0)
+
+	for rows.Next() {
+		var b model.Balance
+
+		err = rows.Scan(&b.Currency, &b.Value, &b.UpdatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		balances = append(balances, b)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return balances, nil
+}
+
+// GetBalance returns the user balance in the currency, zero when the user has no account in it yet.
+func (r *LedgerRepo) GetBalance(ctx context.Context, userID uuid.UUID, currency yoomodel.Currency) (string, error) {
+	const op = "repo.postgres.ledger.GetBalance"
+
+	var balance string
+
+	err := r.db.QueryRowContext(ctx, `SELECT COALESCE((SELECT balance FROM accounts WHERE type = $1 AND user_id = $2 AND currency = $3), 0)::text`,
+		model.UserAccount, userID, currency).Scan(&balance)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	return balance, nil
+}
+
+func (r *LedgerRepo) GetBalanceChanges(ctx context.Context, filter model.LedgerFilter) ([]model.BalanceChange, error) {
+	const op = "repo.postgres.ledger.GetBalanceChanges"
+
+	var (
+		conditions []string
+		args       []any
+	)
+
+	addCondition := func(cond string, values ...any) {
+		for _, v := range values {
+			args = append(args, v)
+			cond = strings.Replace(cond, "?", fmt.Sprintf("$%d", len(args)), 1)
+		}
+		conditions = append(conditions, cond)
+	}
+
+	addCondition("a.type = ?", model.UserAccount)
+	addCondition("a.user_id = ?", filter.UserID)
+
+	if filter.Currency != "" {
+		addCondition("a.currency = ?", filter.Currency)
+	}
+
+	direction, comparison := "ASC", ">"
+	if filter.Order == model.SortDesc {
+		direction, comparison = "DESC", "<"
+	}
+
+	if filter.Cursor != nil {
+		addCondition("(e.created_at, e.id) "+comparison+" (?, ?)", filter.Cursor.CreatedAt, filter.Cursor.ID)
+	}
+
+	query := `SELECT e.id, e.posting_id, p.kind, p.reference_id, e.value, a.currency, e.created_at
+		FROM ledger_entries e
+		JOIN accounts a ON a.id = e.account_id
+		JOIN ledger_postings p ON p.id = e.posting_id
+		WHERE ` + strings.Join(conditions, " AND ")
+
+	args = append(args, filter.Limit)
+	query += fmt.Sprintf(" ORDER BY e.created_at %s, e.id %s LIMIT $%d", direction, direction, len(args))
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer rows.Close()
+
+	changes := make([]model.BalanceChange, 0, filter.Limit)
+
+	for rows.Next() {
+		var c model.BalanceChange
+
+		err = rows.Scan(&c.ID, &c.PostingID, &c.Kind, &c.ReferenceID, &c.Value, &c.Currency, &c.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		changes = append(changes, c)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return changes, nil
+}
@@ -3,17 +3,24 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"github.com/eclipsemode/go-yookassa-sdk/yookassa/model"
 	"github.com/imperatorofdwelling/payment-svc/internal/domain/model"
 	"go.uber.org/zap"
+	"strings"
 	"time"
 )
 
+var ErrLogNotFound = errors.New("log not found")
+
 type ILogsRepo interface {
-	InsertLog(context.Context, *model.Log) error
+	InsertLog(ctx context.Context, log *model.Log, source model.StatusSource) error
 	CheckTransactionIDExists(ctx context.Context, transactionID string) (bool, error)
-	UpdateLogStatus(ctx context.Context, transactionID string, status yoomodel.TransactionStatus) error
+	GetLogByTransactionID(ctx context.Context, transactionID string) (*model.Log, error)
+	UpdateLogStatus(ctx context.Context, transactionID string, from, to yoomodel.TransactionStatus, source model.StatusSource) (bool, error)
+	GetStatusHistory(ctx context.Context, transactionID string) ([]model.StatusHistory, error)
+	GetLogs(ctx context.Context, filter model.LogFilter) ([]model.Log, error)
 }
 
 type LogsRepo struct {
@@ -25,7 +32,8 @@ func NewLogsRepo(db *sql.DB, log *zap.SugaredLogger) *LogsRepo {
 	return &LogsRepo{db, log}
 }
 
-func (r *LogsRepo) InsertLog(ctx context.Context, p *model.Log) error {
+// InsertLog stores a new transaction together with its initial status history entry.
+func (r *LogsRepo) InsertLog(ctx context.Context, p *model.Log, source model.StatusSource) error {
 	const op = "repo.postgres.logs.InsertLog"
 
 	tx, err := r.db.BeginTx(ctx, nil)
@@ -51,7 +59,12 @@ func (r *LogsRepo) InsertLog(ctx context.Context, p *model.Log) error {
 
 	defer stmtPayment.Close()
 
-	_, err = stmtPayment.ExecContext(ctx, p.TransactionID, p.TransactionType, p.Status, p.Value, p.Currency, time.Now(), time.Now())
+	_, err = stmtPayment.ExecContext(ctx, p.TransactionID, p.TransactionType, p.Status, p.Amount, p.Amount.Currency, time.Now(), time.Now())
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = insertStatusHistory(ctx, tx, p.TransactionID, "", p.Status, source)
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
@@ -85,20 +98,214 @@ func (r *LogsRepo) CheckTransactionIDExists(ctx context.Context, transactionID s
 	return exists, nil
 }
 
-func (r *LogsRepo) UpdateLogStatus(ctx context.Context, transactionID string, status yoomodel.TransactionStatus) error {
+func (r *LogsRepo) GetLogByTransactionID(ctx context.Context, transactionID string) (*model.Log, error) {
+	const op = "repo.postgres.logs.GetLogByTransactionID"
+
+	stmt, err := r.db.PrepareContext(ctx, `SELECT id, transaction_id, transaction_type, status, value, currency, created_at, updated_at FROM logs WHERE transaction_id = $1`)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer stmt.Close()
+
+	l, err := scanLog(stmt.QueryRowContext(ctx, transactionID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%s: %w", op, ErrLogNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &l, nil
+}
+
+// UpdateLogStatus moves the transaction from one status to another and records the change in the status
+// history atomically. The update only applies while the stored status still equals from, so it returns
+// false when a concurrent update got there first.
+func (r *LogsRepo) UpdateLogStatus(ctx context.Context, transactionID string, from, to yoomodel.TransactionStatus, source model.StatusSource) (bool, error) {
 	const op = "repo.postgres.logs.UpdateLogStatus"
 
-	stmt, err := r.db.PrepareContext(ctx, `UPDATE logs SET status = $1 WHERE transaction_id = $2`)
+	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
-		return fmt.Errorf("%s: %w", op, err)
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `UPDATE logs SET status = $1, updated_at = $2 WHERE transaction_id = $3 AND status = $4`)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
 	}
 
 	defer stmt.Close()
 
-	_, err = stmt.ExecContext(ctx, status, transactionID)
+	res, err := stmt.ExecContext(ctx, to, time.Now(), transactionID, from)
 	if err != nil {
-		return fmt.Errorf("%s: %w", op, err)
+		return false, fmt.Errorf("%s: %w", op, err)
 	}
 
-	return nil
+	updated, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if updated != 1 {
+		return false, nil
+	}
+
+	err = insertStatusHistory(ctx, tx, transactionID, from, to, source)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return true, nil
+}
+
+func (r *LogsRepo) GetStatusHistory(ctx context.Context, transactionID string) ([]model.StatusHistory, error) {
+	const op = "repo.postgres.logs.GetStatusHistory"
+
+	history, err := queryStatusHistory(ctx, r.db, transactionID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return history, nil
+}
+
+// GetLogs returns up to filter.Limit logs matching the filter, ordered by (created_at, id) and starting
+// right after filter.Cursor when it is set.
+func (r *LogsRepo) GetLogs(ctx context.Context, filter model.LogFilter) ([]model.Log, error) {
+	const op = "repo.postgres.logs.GetLogs"
+
+	var (
+		conditions []string
+		args       []any
+	)
+
+	addCondition := func(cond string, values ...any) {
+		for _, v := range values {
+			args = append(args, v)
+			cond = strings.Replace(cond, "?", fmt.Sprintf("$%d", len(args)), 1)
+		}
+		conditions = append(conditions, cond)
+	}
+
+	if filter.TransactionType != "" {
+		addCondition("transaction_type = ?", filter.TransactionType)
+	}
+	if filter.Status != "" {
+		addCondition("status = ?", filter.Status)
+	}
+	if filter.Currency != "" {
+		addCondition("currency = ?", filter.Currency)
+	}
+	if filter.AmountFrom != "" {
+		addCondition("value >= ?", filter.AmountFrom)
+	}
+	if filter.AmountTo != "" {
+		addCondition("value <= ?", filter.AmountTo)
+	}
+	if filter.CreatedFrom != nil {
+		addCondition("created_at >= ?", *filter.CreatedFrom)
+	}
+	if filter.CreatedTo != nil {
+		addCondition("created_at < ?", *filter.CreatedTo)
+	}
+
+	direction, comparison := "ASC", ">"
+	if filter.Order == model.SortDesc {
+		direction, comparison = "DESC", "<"
+	}
+
+	if filter.Cursor != nil {
+		addCondition("(created_at, id) "+comparison+" (?, ?)", filter.Cursor.CreatedAt, filter.Cursor.ID)
+	}
+
+	query := `SELECT id, transaction_id, transaction_type, status, value, currency, created_at, updated_at FROM logs`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	args = append(args, filter.Limit)
+	query += fmt.Sprintf(" ORDER BY created_at %s, id %s LIMIT $%d", direction, direction, len(args))
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer rows.Close()
+
+	logs := make([]model.Log, 0, filter.Limit)
+
+	for rows.Next() {
+		l, err := scanLog(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		logs = append(logs, l)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return logs, nil
+}
+
+// scanLog reads the columns selected by the logs queries. The amount is parsed once its currency is known.
+func scanLog(row rowScanner) (model.Log, error) {
+	var (
+		l     model.Log
+		value string
+	)
+
+	err := row.Scan(&l.ID, &l.TransactionID, &l.TransactionType, &l.Status, &value, &l.Amount.Currency, &l.CreatedAt, &l.UpdatedAt)
+	if err != nil {
+		return l, err
+	}
+
+	l.Amount, err = model.ParseMoney(value, l.Amount.Currency)
+	if err != nil {
+		return l, err
+	}
+
+	return l, nil
+}
+
+func insertStatusHistory(ctx context.Context, tx *sql.Tx, transactionID string, from, to yoomodel.TransactionStatus, source model.StatusSource) error {
+	_, err := tx.ExecContext(ctx, `INSERT INTO transaction_status_history(transaction_id, old_status, new_status, source, created_at) VALUES ($1, NULLIF($2, ''), $3, $4, $5)`,
+		transactionID, from, to, source, time.Now())
+	return err
+}
+
+func queryStatusHistory(ctx context.Context, db *sql.DB, transactionID string) ([]model.StatusHistory, error) {
+	rows, err := db.QueryContext(ctx, `SELECT id, transaction_id, COALESCE(old_status, ''), new_status, source, created_at
+		FROM transaction_status_history WHERE transaction_id = $1 ORDER BY created_at, id`, transactionID)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	history := make([]model.StatusHistory, 0)
+
+	for rows.Next() {
+		var h model.StatusHistory
+
+		err = rows.Scan(&h.ID, &h.TransactionID, &h.OldStatus, &h.NewStatus, &h.Source, &h.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		history = append(history, h)
+	}
+
+	return history, rows.Err()
 }
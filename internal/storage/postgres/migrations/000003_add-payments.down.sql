@@ -0,0 +1 @@
+DROP TABLE IF EXISTS payments;
\ No newline at end of file
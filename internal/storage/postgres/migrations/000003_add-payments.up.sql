@@ -0,0 +1,24 @@
+CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
+
+CREATE TABLE IF NOT EXISTS payments (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    payment_id varchar(255) NOT NULL UNIQUE,
+    status varchar(255) NOT NULL DEFAULT 'pending',
+    value numeric(10,2) NOT NULL,
+    currency varchar(10) NOT NULL,
+    description varchar(128),
+    metadata jsonb,
+    confirmation_type varchar(100),
+    confirmation_url text,
+    confirmation_token varchar(255),
+    payment_method_type varchar(100),
+    paid boolean NOT NULL DEFAULT false,
+    refundable boolean NOT NULL DEFAULT false,
+    capture boolean NOT NULL DEFAULT false,
+    test boolean NOT NULL DEFAULT false,
+    capture_deadline timestamp,
+    captured_at timestamp,
+    idempotence_key varchar(255) NOT NULL,
+    created_at timestamp DEFAULT CURRENT_TIMESTAMP,
+    updated_at timestamp DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,6 @@
+BEGIN;
+
+DROP TABLE IF EXISTS payouts_status_history;
+DROP TABLE IF EXISTS payouts;
+
+COMMIT;
@@ -0,0 +1,28 @@
+CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
+
+CREATE TABLE IF NOT EXISTS payouts (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    payout_id varchar(255) NOT NULL UNIQUE,
+    status varchar(255) NOT NULL DEFAULT 'pending',
+    value numeric(10,2) NOT NULL,
+    currency varchar(10) NOT NULL,
+    description varchar(128),
+    metadata jsonb,
+    card_id UUID REFERENCES bank_cards(id) ON DELETE SET NULL,
+    payout_token varchar(100),
+    user_id UUID,
+    test boolean NOT NULL DEFAULT false,
+    created_at timestamp DEFAULT CURRENT_TIMESTAMP,
+    updated_at timestamp DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS payouts_user_id_idx ON payouts(user_id);
+
+CREATE TABLE IF NOT EXISTS payouts_status_history (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    payout_id varchar(255) NOT NULL REFERENCES payouts(payout_id) ON DELETE CASCADE,
+    status varchar(255) NOT NULL,
+    created_at timestamp DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS payouts_status_history_payout_id_idx ON payouts_status_history(payout_id);
@@ -0,0 +1,16 @@
+CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
+
+CREATE TABLE IF NOT EXISTS refunds (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    refund_id varchar(255) NOT NULL UNIQUE,
+    payment_id varchar(255) NOT NULL REFERENCES payments(payment_id),
+    status varchar(255) NOT NULL DEFAULT 'pending',
+    value numeric(10,2) NOT NULL,
+    currency varchar(10) NOT NULL,
+    description varchar(250),
+    idempotence_key varchar(255) NOT NULL,
+    created_at timestamp DEFAULT CURRENT_TIMESTAMP,
+    updated_at timestamp DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS refunds_payment_id_idx ON refunds(payment_id);
@@ -0,0 +1,19 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS payouts_status_history (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    payout_id varchar(255) NOT NULL REFERENCES payouts(payout_id) ON DELETE CASCADE,
+    status varchar(255) NOT NULL,
+    created_at timestamp DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS payouts_status_history_payout_id_idx ON payouts_status_history(payout_id);
+
+INSERT INTO payouts_status_history(payout_id, status, created_at)
+SELECT h.transaction_id, h.new_status, h.created_at
+FROM transaction_status_history h
+JOIN payouts p ON p.payout_id = h.transaction_id;
+
+DROP TABLE IF EXISTS transaction_status_history;
+
+COMMIT;
@@ -0,0 +1,24 @@
+CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
+
+CREATE TABLE IF NOT EXISTS transaction_status_history (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    transaction_id varchar(255) NOT NULL REFERENCES logs(transaction_id) ON DELETE CASCADE,
+    old_status varchar(255),
+    new_status varchar(255) NOT NULL,
+    source varchar(50) NOT NULL,
+    created_at timestamp DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS transaction_status_history_transaction_id_idx ON transaction_status_history(transaction_id, created_at);
+
+-- payout-only history is superseded by the generic one
+INSERT INTO transaction_status_history(transaction_id, old_status, new_status, source, created_at)
+SELECT h.payout_id,
+       LAG(h.status) OVER (PARTITION BY h.payout_id ORDER BY h.created_at),
+       h.status,
+       'legacy',
+       h.created_at
+FROM payouts_status_history h
+JOIN logs l ON l.transaction_id = h.payout_id;
+
+DROP TABLE IF EXISTS payouts_status_history;
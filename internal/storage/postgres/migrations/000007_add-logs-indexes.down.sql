@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS logs_currency_created_at_idx;
+DROP INDEX IF EXISTS logs_status_created_at_idx;
+DROP INDEX IF EXISTS logs_transaction_type_created_at_idx;
+DROP INDEX IF EXISTS logs_created_at_id_idx;
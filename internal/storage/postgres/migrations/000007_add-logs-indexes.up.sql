@@ -0,0 +1,4 @@
+CREATE INDEX IF NOT EXISTS logs_created_at_id_idx ON logs(created_at, id);
+CREATE INDEX IF NOT EXISTS logs_transaction_type_created_at_idx ON logs(transaction_type, created_at, id);
+CREATE INDEX IF NOT EXISTS logs_status_created_at_idx ON logs(status, created_at, id);
+CREATE INDEX IF NOT EXISTS logs_currency_created_at_idx ON logs(currency, created_at, id);
@@ -0,0 +1,25 @@
+CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
+
+CREATE TABLE IF NOT EXISTS reconciliation_reports (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    date date NOT NULL UNIQUE,
+    payments_checked integer NOT NULL DEFAULT 0,
+    payouts_checked integer NOT NULL DEFAULT 0,
+    created_at timestamp DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS reconciliation_discrepancies (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    report_id UUID NOT NULL REFERENCES reconciliation_reports(id) ON DELETE CASCADE,
+    transaction_id varchar(255) NOT NULL,
+    transaction_type varchar(255) NOT NULL,
+    kind varchar(50) NOT NULL,
+    local_status varchar(255),
+    upstream_status varchar(255),
+    local_value numeric(10,2),
+    upstream_value numeric(10,2),
+    local_currency varchar(10),
+    upstream_currency varchar(10)
+);
+
+CREATE INDEX IF NOT EXISTS reconciliation_discrepancies_report_id_idx ON reconciliation_discrepancies(report_id);
@@ -0,0 +1,14 @@
+CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
+
+CREATE TABLE IF NOT EXISTS scheduled_jobs (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    kind varchar(100) NOT NULL,
+    payload jsonb NOT NULL DEFAULT '{}',
+    run_at timestamp NOT NULL,
+    status varchar(50) NOT NULL DEFAULT 'pending',
+    last_error text,
+    created_at timestamp DEFAULT CURRENT_TIMESTAMP,
+    updated_at timestamp DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS scheduled_jobs_status_run_at_idx ON scheduled_jobs(status, run_at);
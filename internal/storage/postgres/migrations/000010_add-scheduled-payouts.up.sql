@@ -0,0 +1,17 @@
+CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
+
+CREATE TABLE IF NOT EXISTS scheduled_payouts (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    job_id UUID REFERENCES scheduled_jobs(id) ON DELETE SET NULL,
+    payout jsonb NOT NULL,
+    idempotence_key varchar(255) NOT NULL UNIQUE,
+    execute_at timestamp NOT NULL,
+    status varchar(50) NOT NULL DEFAULT 'pending',
+    payout_id varchar(255),
+    last_error text,
+    started_at timestamp,
+    created_at timestamp DEFAULT CURRENT_TIMESTAMP,
+    updated_at timestamp DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS scheduled_payouts_status_execute_at_idx ON scheduled_payouts(status, execute_at);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS payments_metadata_user_id_idx;
+DROP TABLE IF EXISTS payout_rules;
@@ -0,0 +1,21 @@
+CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
+
+CREATE TABLE IF NOT EXISTS payout_rules (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL,
+    card_id UUID NOT NULL REFERENCES bank_cards(id) ON DELETE CASCADE,
+    period varchar(20) NOT NULL,
+    weekday smallint,
+    day_of_month smallint,
+    min_amount numeric(10,2) NOT NULL,
+    currency varchar(10) NOT NULL,
+    enabled boolean NOT NULL DEFAULT true,
+    job_id UUID REFERENCES scheduled_jobs(id) ON DELETE SET NULL,
+    next_run_at timestamp,
+    last_run_at timestamp,
+    created_at timestamp DEFAULT CURRENT_TIMESTAMP,
+    updated_at timestamp DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS payout_rules_user_id_idx ON payout_rules(user_id);
+CREATE INDEX IF NOT EXISTS payments_metadata_user_id_idx ON payments((metadata->>'user_id'));
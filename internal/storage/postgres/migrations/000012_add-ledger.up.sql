@@ -0,0 +1,72 @@
+CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
+
+CREATE TABLE IF NOT EXISTS accounts (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    type varchar(20) NOT NULL,
+    user_id UUID,
+    currency varchar(10) NOT NULL,
+    balance numeric(12,2) NOT NULL DEFAULT 0,
+    created_at timestamp DEFAULT CURRENT_TIMESTAMP,
+    updated_at timestamp DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT accounts_owner_check CHECK ((type = 'user') = (user_id IS NOT NULL)),
+    CONSTRAINT accounts_type_user_id_currency_key UNIQUE NULLS NOT DISTINCT (type, user_id, currency)
+);
+
+CREATE TABLE IF NOT EXISTS ledger_postings (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    kind varchar(50) NOT NULL,
+    reference_id varchar(255) NOT NULL,
+    currency varchar(10) NOT NULL,
+    created_at timestamp DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (kind, reference_id)
+);
+
+CREATE TABLE IF NOT EXISTS ledger_entries (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    posting_id UUID NOT NULL REFERENCES ledger_postings(id) ON DELETE CASCADE,
+    account_id UUID NOT NULL REFERENCES accounts(id),
+    value numeric(12,2) NOT NULL CHECK (value <> 0),
+    created_at timestamp DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS ledger_entries_account_id_created_at_idx ON ledger_entries(account_id, created_at, id);
+CREATE INDEX IF NOT EXISTS ledger_entries_posting_id_idx ON ledger_entries(posting_id);
+
+-- post the transactions stored before the ledger existed, the same way the services post new ones
+CREATE TEMPORARY TABLE ledger_backfill AS
+SELECT 'payment' AS kind, payment_id AS reference_id, (metadata->>'user_id')::uuid AS user_id, currency, value, updated_at AS created_at
+FROM payments
+WHERE status = 'succeeded' AND metadata->>'user_id' ~* '^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$'
+UNION ALL
+SELECT 'refund', r.refund_id, (p.metadata->>'user_id')::uuid, r.currency, -r.value, r.updated_at
+FROM refunds r JOIN payments p ON p.payment_id = r.payment_id
+WHERE r.status = 'succeeded' AND p.metadata->>'user_id' ~* '^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$'
+UNION ALL
+SELECT 'payout', payout_id, user_id, currency, -value, created_at
+FROM payouts
+WHERE status <> 'canceled' AND user_id IS NOT NULL;
+
+INSERT INTO accounts(type, user_id, currency)
+SELECT DISTINCT 'user', user_id, currency FROM ledger_backfill
+UNION
+SELECT DISTINCT 'clearing', NULL::uuid, currency FROM ledger_backfill;
+
+INSERT INTO ledger_postings(kind, reference_id, currency, created_at)
+SELECT kind, reference_id, currency, created_at FROM ledger_backfill;
+
+INSERT INTO ledger_entries(posting_id, account_id, value, created_at)
+SELECT lp.id, a.id, b.value, b.created_at
+FROM ledger_backfill b
+JOIN ledger_postings lp ON lp.kind = b.kind AND lp.reference_id = b.reference_id
+JOIN accounts a ON a.type = 'user' AND a.user_id = b.user_id AND a.currency = b.currency
+UNION ALL
+SELECT lp.id, a.id, -b.value, b.created_at
+FROM ledger_backfill b
+JOIN ledger_postings lp ON lp.kind = b.kind AND lp.reference_id = b.reference_id
+JOIN accounts a ON a.type = 'clearing' AND a.currency = b.currency;
+
+UPDATE accounts a SET balance = t.total
+FROM (SELECT account_id, SUM(value) AS total FROM ledger_entries GROUP BY account_id) t
+WHERE a.id = t.account_id;
+
+DROP TABLE ledger_backfill;
@@ -0,0 +1,8 @@
+ALTER TABLE ledger_entries ALTER COLUMN value TYPE numeric(12,2);
+ALTER TABLE accounts ALTER COLUMN balance TYPE numeric(12,2);
+ALTER TABLE payout_rules ALTER COLUMN min_amount TYPE numeric(10,2);
+ALTER TABLE reconciliation_discrepancies ALTER COLUMN local_value TYPE numeric(10,2), ALTER COLUMN upstream_value TYPE numeric(10,2);
+ALTER TABLE refunds ALTER COLUMN value TYPE numeric(10,2);
+ALTER TABLE payouts ALTER COLUMN value TYPE numeric(10,2);
+ALTER TABLE payments ALTER COLUMN value TYPE numeric(10,2);
+ALTER TABLE logs ALTER COLUMN value TYPE numeric(10,2);
@@ -0,0 +1,10 @@
+-- amounts are written with as many decimal places as their currency has (0 for JPY, 3 for KWD),
+-- so the columns keep the scale of the written value and are no longer capped at 99,999,999.99
+ALTER TABLE logs ALTER COLUMN value TYPE numeric;
+ALTER TABLE payments ALTER COLUMN value TYPE numeric;
+ALTER TABLE payouts ALTER COLUMN value TYPE numeric;
+ALTER TABLE refunds ALTER COLUMN value TYPE numeric;
+ALTER TABLE reconciliation_discrepancies ALTER COLUMN local_value TYPE numeric, ALTER COLUMN upstream_value TYPE numeric;
+ALTER TABLE payout_rules ALTER COLUMN min_amount TYPE numeric;
+ALTER TABLE accounts ALTER COLUMN balance TYPE numeric;
+ALTER TABLE ledger_entries ALTER COLUMN value TYPE numeric;
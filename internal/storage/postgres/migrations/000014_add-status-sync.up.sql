@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS status_sync_queue (
+    transaction_id varchar(255) PRIMARY KEY,
+    transaction_type varchar(50) NOT NULL,
+    state varchar(50) NOT NULL DEFAULT 'pending',
+    attempts integer NOT NULL DEFAULT 0,
+    next_attempt_at timestamp NOT NULL,
+    last_error text,
+    created_at timestamp DEFAULT CURRENT_TIMESTAMP,
+    updated_at timestamp DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS status_sync_queue_state_next_attempt_at_idx ON status_sync_queue(state, next_attempt_at);
+
+-- payouts polled by goroutines of the previous release have no other way to get their final status
+INSERT INTO status_sync_queue(transaction_id, transaction_type, next_attempt_at)
+SELECT payout_id, 'payout', CURRENT_TIMESTAMP FROM payouts WHERE status NOT IN ('succeeded', 'canceled')
+ON CONFLICT DO NOTHING;
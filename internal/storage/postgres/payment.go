@@ -1,11 +1,23 @@
 package postgres
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/imperatorofdwelling/payment-svc/internal/domain/model"
 	"go.uber.org/zap"
+	"time"
 )
 
+var ErrPaymentNotFound = errors.New("payment not found")
+
 type IPaymentRepo interface {
+	InsertPayment(context.Context, *model.Payment) error
+	GetPaymentByID(ctx context.Context, paymentID string) (*model.Payment, error)
+	UpdatePayment(context.Context, *model.Payment) error
+	LockPayment(ctx context.Context, paymentID string) (release func(), err error)
 }
 
 type PaymentRepo struct {
@@ -19,3 +31,134 @@ func NewPaymentRepo(db *sql.DB, log *zap.SugaredLogger) *PaymentRepo {
 		log: log,
 	}
 }
+
+func (r *PaymentRepo) InsertPayment(ctx context.Context, p *model.Payment) error {
+	const op = "repo.postgres.payment.InsertPayment"
+
+	metadata, err := marshalMetadata(p.Metadata)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	stmt, err := r.db.PrepareContext(ctx, `INSERT INTO payments(payment_id, status, value, currency, description, metadata, confirmation_type, confirmation_url, confirmation_token, payment_method_type, paid, refundable, capture, test, capture_deadline, captured_at, idempotence_key, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19)
+		ON CONFLICT (payment_id) DO NOTHING`)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer stmt.Close()
+
+	_, err = stmt.ExecContext(ctx, p.PaymentID, p.Status, p.Amount, p.Amount.Currency, p.Description, metadata, p.ConfirmationType, p.ConfirmationURL, p.ConfirmationToken, p.PaymentMethodType, p.Paid, p.Refundable, p.Capture, p.Test, p.CaptureDeadline, p.CapturedAt, p.IdempotenceKey, time.Now(), time.Now())
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *PaymentRepo) GetPaymentByID(ctx context.Context, paymentID string) (*model.Payment, error) {
+	const op = "repo.postgres.payment.GetPaymentByID"
+
+	stmt, err := r.db.PrepareContext(ctx, `SELECT id, payment_id, status, value, currency, COALESCE(description, ''), metadata, COALESCE(confirmation_type, ''), COALESCE(confirmation_url, ''), COALESCE(confirmation_token, ''), COALESCE(payment_method_type, ''), paid, refundable, capture, test, capture_deadline, captured_at, idempotence_key, created_at, updated_at
+		FROM payments WHERE payment_id = $1`)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer stmt.Close()
+
+	var (
+		p        model.Payment
+		value    string
+		metadata []byte
+	)
+
+	err = stmt.QueryRowContext(ctx, paymentID).Scan(&p.ID, &p.PaymentID, &p.Status, &value, &p.Amount.Currency, &p.Description, &metadata, &p.ConfirmationType, &p.ConfirmationURL, &p.ConfirmationToken, &p.PaymentMethodType, &p.Paid, &p.Refundable, &p.Capture, &p.Test, &p.CaptureDeadline, &p.CapturedAt, &p.IdempotenceKey, &p.CreatedAt, &p.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%s: %w", op, ErrPaymentNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	p.Amount, err = model.ParseMoney(value, p.Amount.Currency)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if len(metadata) > 0 {
+		if err := json.Unmarshal(metadata, &p.Metadata); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return &p, nil
+}
+
+// UpdatePayment overwrites the provider-controlled state of the payment, keeping its creation data intact.
+func (r *PaymentRepo) UpdatePayment(ctx context.Context, p *model.Payment) error {
+	const op = "repo.postgres.payment.UpdatePayment"
+
+	stmt, err := r.db.PrepareContext(ctx, `UPDATE payments SET status = $1, value = $2, currency = $3, payment_method_type = $4, paid = $5, refundable = $6, capture_deadline = $7, captured_at = $8, updated_at = $9
+		WHERE payment_id = $10`)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer stmt.Close()
+
+	res, err := stmt.ExecContext(ctx, p.Status, p.Amount, p.Amount.Currency, p.PaymentMethodType, p.Paid, p.Refundable, p.CaptureDeadline, p.CapturedAt, time.Now(), p.PaymentID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	updated, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if updated == 0 {
+		return fmt.Errorf("%s: %w", op, ErrPaymentNotFound)
+	}
+
+	return nil
+}
+
+// LockPayment locks the payment row until release is called, so changes of the same payment made across calls
+// to the provider run one at a time on every replica. FOR NO KEY UPDATE does not block inserting rows that
+// reference the payment, so the holder can still store refunds while the lock is held. The lock is also released
+// when ctx is done, the transaction holding it is then rolled back.
+func (r *PaymentRepo) LockPayment(ctx context.Context, paymentID string) (func(), error) {
+	const op = "repo.postgres.payment.LockPayment"
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var id string
+
+	err = tx.QueryRowContext(ctx, `SELECT payment_id FROM payments WHERE payment_id = $1 FOR NO KEY UPDATE`, paymentID).Scan(&id)
+	if err != nil {
+		_ = tx.Rollback()
+
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%s: %w", op, ErrPaymentNotFound)
+		}
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// nothing is written in the transaction, rolling it back just releases the lock
+	return func() { _ = tx.Rollback() }, nil
+}
+
+// marshalMetadata converts arbitrary provider metadata to a jsonb value, keeping NULL for empty metadata.
+func marshalMetadata(metadata any) ([]byte, error) {
+	if metadata == nil {
+		return nil, nil
+	}
+
+	return json.Marshal(metadata)
+}
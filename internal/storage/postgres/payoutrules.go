@@ -0,0 +1,167 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/imperatorofdwelling/payment-svc/internal/domain/model"
+	"go.uber.org/zap"
+	"time"
+)
+
+var ErrPayoutRuleNotFound = errors.New("payout rule not found")
+
+const payoutRuleColumns = `id, user_id, card_id, period, weekday, day_of_month, min_amount, currency, enabled, job_id, next_run_at, last_run_at, created_at, updated_at`
+
+type IPayoutRulesRepo interface {
+	InsertPayoutRule(ctx context.Context, rule *model.PayoutRule) error
+	GetPayoutRuleByID(ctx context.Context, id uuid.UUID) (*model.PayoutRule, error)
+	GetPayoutRules(ctx context.Context, userID *uuid.UUID) ([]model.PayoutRule, error)
+	UpdatePayoutRule(ctx context.Context, rule *model.PayoutRule) error
+	SetPayoutRuleSchedule(ctx context.Context, id uuid.UUID, jobID *uuid.UUID, nextRunAt, lastRunAt *time.Time) error
+	DeletePayoutRule(ctx context.Context, id uuid.UUID) error
+}
+
+type PayoutRulesRepo struct {
+	db  *sql.DB
+	log *zap.SugaredLogger
+}
+
+func NewPayoutRulesRepo(db *sql.DB, log *zap.SugaredLogger) *PayoutRulesRepo {
+	return &PayoutRulesRepo{db, log}
+}
+
+func (r *PayoutRulesRepo) InsertPayoutRule(ctx context.Context, rule *model.PayoutRule) error {
+	const op = "repo.postgres.payoutrules.InsertPayoutRule"
+
+	err := r.db.QueryRowContext(ctx, `INSERT INTO payout_rules(user_id, card_id, period, weekday, day_of_month, min_amount, currency, enabled, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $9) RETURNING id, created_at, updated_at`,
+		rule.UserID, rule.CardID, rule.Period, rule.Weekday, rule.DayOfMonth, rule.MinAmount, rule.Currency, rule.Enabled, time.Now()).
+		Scan(&rule.ID, &rule.CreatedAt, &rule.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *PayoutRulesRepo) GetPayoutRuleByID(ctx context.Context, id uuid.UUID) (*model.PayoutRule, error) {
+	const op = "repo.postgres.payoutrules.GetPayoutRuleByID"
+
+	rule, err := scanPayoutRule(r.db.QueryRowContext(ctx, `SELECT `+payoutRuleColumns+` FROM payout_rules WHERE id = $1`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%s: %w", op, ErrPayoutRuleNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return rule, nil
+}
+
+// GetPayoutRules returns the rules of a user, or every rule when userID is nil.
+func (r *PayoutRulesRepo) GetPayoutRules(ctx context.Context, userID *uuid.UUID) ([]model.PayoutRule, error) {
+	const op = "repo.postgres.payoutrules.GetPayoutRules"
+
+	rows, err := r.db.QueryContext(ctx, `SELECT `+payoutRuleColumns+` FROM payout_rules
+		WHERE $1::uuid IS NULL OR user_id = $1 ORDER BY created_at, id`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer rows.Close()
+
+	rules := make([]model.PayoutRule, 0)
+
+	for rows.Next() {
+		rule, err := scanPayoutRule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		rules = append(rules, *rule)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return rules, nil
+}
+
+// UpdatePayoutRule saves the rule settings. The schedule is saved separately by SetPayoutRuleSchedule.
+func (r *PayoutRulesRepo) UpdatePayoutRule(ctx context.Context, rule *model.PayoutRule) error {
+	const op = "repo.postgres.payoutrules.UpdatePayoutRule"
+
+	err := r.db.QueryRowContext(ctx, `UPDATE payout_rules SET card_id = $1, period = $2, weekday = $3, day_of_month = $4, min_amount = $5, currency = $6, enabled = $7, updated_at = $8
+		WHERE id = $9 RETURNING updated_at`,
+		rule.CardID, rule.Period, rule.Weekday, rule.DayOfMonth, rule.MinAmount, rule.Currency, rule.Enabled, time.Now(), rule.ID).Scan(&rule.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%s: %w", op, ErrPayoutRuleNotFound)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// SetPayoutRuleSchedule stores the job of the next run. A nil lastRunAt keeps the previous value.
+func (r *PayoutRulesRepo) SetPayoutRuleSchedule(ctx context.Context, id uuid.UUID, jobID *uuid.UUID, nextRunAt, lastRunAt *time.Time) error {
+	const op = "repo.postgres.payoutrules.SetPayoutRuleSchedule"
+
+	_, err := r.db.ExecContext(ctx, `UPDATE payout_rules SET job_id = $1, next_run_at = $2, last_run_at = COALESCE($3, last_run_at), updated_at = $4 WHERE id = $5`,
+		jobID, nextRunAt, lastRunAt, time.Now(), id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *PayoutRulesRepo) DeletePayoutRule(ctx context.Context, id uuid.UUID) error {
+	const op = "repo.postgres.payoutrules.DeletePayoutRule"
+
+	res, err := r.db.ExecContext(ctx, `DELETE FROM payout_rules WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if deleted == 0 {
+		return fmt.Errorf("%s: %w", op, ErrPayoutRuleNotFound)
+	}
+
+	return nil
+}
+
+func scanPayoutRule(row rowScanner) (*model.PayoutRule, error) {
+	var (
+		rule                model.PayoutRule
+		weekday, dayOfMonth sql.NullInt16
+	)
+
+	err := row.Scan(&rule.ID, &rule.UserID, &rule.CardID, &rule.Period, &weekday, &dayOfMonth, &rule.MinAmount, &rule.Currency,
+		&rule.Enabled, &rule.JobID, &rule.NextRunAt, &rule.LastRunAt, &rule.CreatedAt, &rule.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if weekday.Valid {
+		v := int(weekday.Int16)
+		rule.Weekday = &v
+	}
+
+	if dayOfMonth.Valid {
+		v := int(dayOfMonth.Int16)
+		rule.DayOfMonth = &v
+	}
+
+	return &rule, nil
+}
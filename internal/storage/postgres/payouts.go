@@ -1,11 +1,23 @@
 package postgres
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	yoomodel "github.com/eclipsemode/go-yookassa-sdk/yookassa/model"
+	"github.com/imperatorofdwelling/payment-svc/internal/domain/model"
 	"go.uber.org/zap"
+	"time"
 )
 
+var ErrPayoutNotFound = errors.New("payout not found")
+
 type IPayoutsRepo interface {
+	InsertPayout(context.Context, *model.Payout) error
+	UpdatePayoutStatus(ctx context.Context, payoutID string, status yoomodel.TransactionStatus) error
+	GetPayoutByID(ctx context.Context, payoutID string) (*model.Payout, error)
 }
 
 type PayoutsRepo struct {
@@ -16,3 +28,84 @@ type PayoutsRepo struct {
 func NewPayoutsRepo(db *sql.DB, log *zap.SugaredLogger) *PayoutsRepo {
 	return &PayoutsRepo{db, log}
 }
+
+func (r *PayoutsRepo) InsertPayout(ctx context.Context, p *model.Payout) error {
+	const op = "repo.postgres.payouts.InsertPayout"
+
+	metadata, err := marshalMetadata(p.Metadata)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	stmt, err := r.db.PrepareContext(ctx, `INSERT INTO payouts(payout_id, status, value, currency, description, metadata, card_id, payout_token, user_id, test, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		ON CONFLICT (payout_id) DO NOTHING`)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer stmt.Close()
+
+	_, err = stmt.ExecContext(ctx, p.PayoutID, p.Status, p.Value, p.Currency, p.Description, metadata, p.CardID, p.PayoutToken, p.UserID, p.Test, time.Now(), time.Now())
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *PayoutsRepo) UpdatePayoutStatus(ctx context.Context, payoutID string, status yoomodel.TransactionStatus) error {
+	const op = "repo.postgres.payouts.UpdatePayoutStatus"
+
+	stmt, err := r.db.PrepareContext(ctx, `UPDATE payouts SET status = $1, updated_at = $2 WHERE payout_id = $3`)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer stmt.Close()
+
+	_, err = stmt.ExecContext(ctx, status, time.Now(), payoutID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *PayoutsRepo) GetPayoutByID(ctx context.Context, payoutID string) (*model.Payout, error) {
+	const op = "repo.postgres.payouts.GetPayoutByID"
+
+	stmt, err := r.db.PrepareContext(ctx, `SELECT id, payout_id, status, value, currency, COALESCE(description, ''), metadata, card_id, COALESCE(payout_token, ''), user_id, test, created_at, updated_at
+		FROM payouts WHERE payout_id = $1`)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer stmt.Close()
+
+	var (
+		p        model.Payout
+		metadata []byte
+	)
+
+	err = stmt.QueryRowContext(ctx, payoutID).Scan(&p.ID, &p.PayoutID, &p.Status, &p.Value, &p.Currency, &p.Description, &metadata, &p.CardID, &p.PayoutToken, &p.UserID, &p.Test, &p.CreatedAt, &p.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%s: %w", op, ErrPayoutNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if len(metadata) > 0 {
+		if err := json.Unmarshal(metadata, &p.Metadata); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	p.StatusHistory, err = queryStatusHistory(ctx, r.db, payoutID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &p, nil
+}
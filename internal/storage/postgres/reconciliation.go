@@ -0,0 +1,121 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/imperatorofdwelling/payment-svc/internal/domain/model"
+	"go.uber.org/zap"
+	"time"
+)
+
+var ErrReportNotFound = errors.New("reconciliation report not found")
+
+type IReconciliationRepo interface {
+	SaveReport(ctx context.Context, report *model.ReconciliationReport) error
+	GetReportByDate(ctx context.Context, date time.Time) (*model.ReconciliationReport, error)
+}
+
+type ReconciliationRepo struct {
+	db  *sql.DB
+	log *zap.SugaredLogger
+}
+
+func NewReconciliationRepo(db *sql.DB, log *zap.SugaredLogger) *ReconciliationRepo {
+	return &ReconciliationRepo{db, log}
+}
+
+// SaveReport stores the report and its discrepancies, replacing an earlier report for the same date.
+func (r *ReconciliationRepo) SaveReport(ctx context.Context, report *model.ReconciliationReport) error {
+	const op = "repo.postgres.reconciliation.SaveReport"
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM reconciliation_reports WHERE date = $1`, report.Date)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = tx.QueryRowContext(ctx, `INSERT INTO reconciliation_reports(date, payments_checked, payouts_checked, created_at)
+		VALUES ($1, $2, $3, $4) RETURNING id, created_at`,
+		report.Date, report.PaymentsChecked, report.PayoutsChecked, time.Now()).Scan(&report.ID, &report.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	stmt, err := tx.PrepareContext(ctx, `INSERT INTO reconciliation_discrepancies(report_id, transaction_id, transaction_type, kind, local_status, upstream_status, local_value, upstream_value, local_currency, upstream_currency)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), NULLIF($6, ''), NULLIF($7, '')::numeric, NULLIF($8, '')::numeric, NULLIF($9, ''), NULLIF($10, ''))
+		RETURNING id`)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer stmt.Close()
+
+	for i := range report.Discrepancies {
+		d := &report.Discrepancies[i]
+
+		err = stmt.QueryRowContext(ctx, report.ID, d.TransactionID, d.TransactionType, d.Kind, d.LocalStatus, d.UpstreamStatus,
+			d.LocalValue, d.UpstreamValue, d.LocalCurrency, d.UpstreamCurrency).Scan(&d.ID)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *ReconciliationRepo) GetReportByDate(ctx context.Context, date time.Time) (*model.ReconciliationReport, error) {
+	const op = "repo.postgres.reconciliation.GetReportByDate"
+
+	var report model.ReconciliationReport
+
+	err := r.db.QueryRowContext(ctx, `SELECT id, date, payments_checked, payouts_checked, created_at FROM reconciliation_reports WHERE date = $1`, date).
+		Scan(&report.ID, &report.Date, &report.PaymentsChecked, &report.PayoutsChecked, &report.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%s: %w", op, ErrReportNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := r.db.QueryContext(ctx, `SELECT id, transaction_id, transaction_type, kind, COALESCE(local_status, ''), COALESCE(upstream_status, ''),
+		COALESCE(local_value::text, ''), COALESCE(upstream_value::text, ''), COALESCE(local_currency, ''), COALESCE(upstream_currency, '')
+		FROM reconciliation_discrepancies WHERE report_id = $1 ORDER BY transaction_id, kind`, report.ID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer rows.Close()
+
+	report.Discrepancies = make([]model.Discrepancy, 0)
+
+	for rows.Next() {
+		var d model.Discrepancy
+
+		err = rows.Scan(&d.ID, &d.TransactionID, &d.TransactionType, &d.Kind, &d.LocalStatus, &d.UpstreamStatus,
+			&d.LocalValue, &d.UpstreamValue, &d.LocalCurrency, &d.UpstreamCurrency)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		report.Discrepancies = append(report.Discrepancies, d)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &report, nil
+}
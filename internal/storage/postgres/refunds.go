@@ -0,0 +1,152 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	yoomodel "github.com/eclipsemode/go-yookassa-sdk/yookassa/model"
+	"github.com/imperatorofdwelling/payment-svc/internal/domain/model"
+	"go.uber.org/zap"
+	"time"
+)
+
+var ErrRefundNotFound = errors.New("refund not found")
+
+type IRefundsRepo interface {
+	InsertRefund(context.Context, *model.Refund) error
+	GetRefundByID(ctx context.Context, refundID string) (*model.Refund, error)
+	GetRefundsByPaymentID(ctx context.Context, paymentID string) ([]model.Refund, error)
+	GetRefundedAmount(ctx context.Context, paymentID string) (string, error)
+	UpdateRefundStatus(ctx context.Context, refundID string, status yoomodel.TransactionStatus) error
+}
+
+type RefundsRepo struct {
+	db  *sql.DB
+	log *zap.SugaredLogger
+}
+
+func NewRefundsRepo(db *sql.DB, log *zap.SugaredLogger) *RefundsRepo {
+	return &RefundsRepo{db, log}
+}
+
+func (r *RefundsRepo) InsertRefund(ctx context.Context, refund *model.Refund) error {
+	const op = "repo.postgres.refunds.InsertRefund"
+
+	stmt, err := r.db.PrepareContext(ctx, `INSERT INTO refunds(refund_id, payment_id, status, value, currency, description, idempotence_key, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (refund_id) DO NOTHING`)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer stmt.Close()
+
+	_, err = stmt.ExecContext(ctx, refund.RefundID, refund.PaymentID, refund.Status, refund.Value, refund.Currency, refund.Description, refund.IdempotenceKey, time.Now(), time.Now())
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *RefundsRepo) GetRefundByID(ctx context.Context, refundID string) (*model.Refund, error) {
+	const op = "repo.postgres.refunds.GetRefundByID"
+
+	stmt, err := r.db.PrepareContext(ctx, `SELECT id, refund_id, payment_id, status, value, currency, COALESCE(description, ''), idempotence_key, created_at, updated_at
+		FROM refunds WHERE refund_id = $1`)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer stmt.Close()
+
+	var refund model.Refund
+
+	err = stmt.QueryRowContext(ctx, refundID).Scan(&refund.ID, &refund.RefundID, &refund.PaymentID, &refund.Status, &refund.Value, &refund.Currency, &refund.Description, &refund.IdempotenceKey, &refund.CreatedAt, &refund.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%s: %w", op, ErrRefundNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &refund, nil
+}
+
+func (r *RefundsRepo) GetRefundsByPaymentID(ctx context.Context, paymentID string) ([]model.Refund, error) {
+	const op = "repo.postgres.refunds.GetRefundsByPaymentID"
+
+	stmt, err := r.db.PrepareContext(ctx, `SELECT id, refund_id, payment_id, status, value, currency, COALESCE(description, ''), idempotence_key, created_at, updated_at
+		FROM refunds WHERE payment_id = $1 ORDER BY created_at`)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer stmt.Close()
+
+	rows, err := stmt.QueryContext(ctx, paymentID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer rows.Close()
+
+	refunds := make([]model.Refund, 0)
+
+	for rows.Next() {
+		var refund model.Refund
+
+		err = rows.Scan(&refund.ID, &refund.RefundID, &refund.PaymentID, &refund.Status, &refund.Value, &refund.Currency, &refund.Description, &refund.IdempotenceKey, &refund.CreatedAt, &refund.UpdatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		refunds = append(refunds, refund)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return refunds, nil
+}
+
+// GetRefundedAmount sums all refunds of the payment that were not canceled, including pending ones.
+func (r *RefundsRepo) GetRefundedAmount(ctx context.Context, paymentID string) (string, error) {
+	const op = "repo.postgres.refunds.GetRefundedAmount"
+
+	stmt, err := r.db.PrepareContext(ctx, `SELECT COALESCE(SUM(value), 0)::text FROM refunds WHERE payment_id = $1 AND status <> $2`)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer stmt.Close()
+
+	var amount string
+
+	err = stmt.QueryRowContext(ctx, paymentID, yoomodel.Canceled).Scan(&amount)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	return amount, nil
+}
+
+func (r *RefundsRepo) UpdateRefundStatus(ctx context.Context, refundID string, status yoomodel.TransactionStatus) error {
+	const op = "repo.postgres.refunds.UpdateRefundStatus"
+
+	stmt, err := r.db.PrepareContext(ctx, `UPDATE refunds SET status = $1, updated_at = $2 WHERE refund_id = $3`)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer stmt.Close()
+
+	_, err = stmt.ExecContext(ctx, status, time.Now(), refundID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,168 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/imperatorofdwelling/payment-svc/internal/domain/model"
+	"go.uber.org/zap"
+	"time"
+)
+
+var ErrScheduledPayoutNotFound = errors.New("scheduled payout not found")
+
+const scheduledPayoutColumns = `id, job_id, payout, idempotence_key, execute_at, status, COALESCE(payout_id, ''), COALESCE(last_error, ''), started_at, created_at, updated_at`
+
+type IScheduledPayoutsRepo interface {
+	InsertScheduledPayout(ctx context.Context, p *model.ScheduledPayout) error
+	SetScheduledPayoutJob(ctx context.Context, id, jobID uuid.UUID) error
+	GetScheduledPayoutByID(ctx context.Context, id uuid.UUID) (*model.ScheduledPayout, error)
+	GetScheduledPayouts(ctx context.Context, status model.ScheduledPayoutStatus) ([]model.ScheduledPayout, error)
+	StartScheduledPayout(ctx context.Context, id uuid.UUID) (bool, error)
+	UpdateScheduledPayoutStatus(ctx context.Context, id uuid.UUID, from, to model.ScheduledPayoutStatus, payoutID, lastError string) (bool, error)
+}
+
+type ScheduledPayoutsRepo struct {
+	db  *sql.DB
+	log *zap.SugaredLogger
+}
+
+func NewScheduledPayoutsRepo(db *sql.DB, log *zap.SugaredLogger) *ScheduledPayoutsRepo {
+	return &ScheduledPayoutsRepo{db, log}
+}
+
+func (r *ScheduledPayoutsRepo) InsertScheduledPayout(ctx context.Context, p *model.ScheduledPayout) error {
+	const op = "repo.postgres.scheduledpayouts.InsertScheduledPayout"
+
+	payout, err := json.Marshal(p.Payout)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = r.db.QueryRowContext(ctx, `INSERT INTO scheduled_payouts(payout, idempotence_key, execute_at, status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $5) RETURNING id, created_at, updated_at`,
+		payout, p.IdempotenceKey, p.ExecuteAt, model.ScheduledPayoutPending, time.Now()).Scan(&p.ID, &p.CreatedAt, &p.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	p.Status = model.ScheduledPayoutPending
+
+	return nil
+}
+
+func (r *ScheduledPayoutsRepo) SetScheduledPayoutJob(ctx context.Context, id, jobID uuid.UUID) error {
+	const op = "repo.postgres.scheduledpayouts.SetScheduledPayoutJob"
+
+	_, err := r.db.ExecContext(ctx, `UPDATE scheduled_payouts SET job_id = $1, updated_at = $2 WHERE id = $3`, jobID, time.Now(), id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *ScheduledPayoutsRepo) GetScheduledPayoutByID(ctx context.Context, id uuid.UUID) (*model.ScheduledPayout, error) {
+	const op = "repo.postgres.scheduledpayouts.GetScheduledPayoutByID"
+
+	p, err := scanScheduledPayout(r.db.QueryRowContext(ctx, `SELECT `+scheduledPayoutColumns+` FROM scheduled_payouts WHERE id = $1`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%s: %w", op, ErrScheduledPayoutNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return p, nil
+}
+
+// GetScheduledPayouts returns scheduled payouts ordered by execution time. An empty status returns all of them.
+func (r *ScheduledPayoutsRepo) GetScheduledPayouts(ctx context.Context, status model.ScheduledPayoutStatus) ([]model.ScheduledPayout, error) {
+	const op = "repo.postgres.scheduledpayouts.GetScheduledPayouts"
+
+	rows, err := r.db.QueryContext(ctx, `SELECT `+scheduledPayoutColumns+` FROM scheduled_payouts
+		WHERE $1 = '' OR status = $1 ORDER BY execute_at, id`, status)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer rows.Close()
+
+	payouts := make([]model.ScheduledPayout, 0)
+
+	for rows.Next() {
+		p, err := scanScheduledPayout(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		payouts = append(payouts, *p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return payouts, nil
+}
+
+// StartScheduledPayout moves a pending payout to processing and records when its execution started.
+// It returns false when the payout is no longer pending.
+func (r *ScheduledPayoutsRepo) StartScheduledPayout(ctx context.Context, id uuid.UUID) (bool, error) {
+	const op = "repo.postgres.scheduledpayouts.StartScheduledPayout"
+
+	now := time.Now()
+
+	res, err := r.db.ExecContext(ctx, `UPDATE scheduled_payouts SET status = $1, started_at = $2, updated_at = $2 WHERE id = $3 AND status = $4`,
+		model.ScheduledPayoutProcessing, now, id, model.ScheduledPayoutPending)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	updated, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return updated == 1, nil
+}
+
+// UpdateScheduledPayoutStatus moves the scheduled payout from one status to another. It returns false when the
+// payout is not in the from status, which is how execution and cancellation exclude each other.
+func (r *ScheduledPayoutsRepo) UpdateScheduledPayoutStatus(ctx context.Context, id uuid.UUID, from, to model.ScheduledPayoutStatus, payoutID, lastError string) (bool, error) {
+	const op = "repo.postgres.scheduledpayouts.UpdateScheduledPayoutStatus"
+
+	res, err := r.db.ExecContext(ctx, `UPDATE scheduled_payouts SET status = $1, payout_id = COALESCE(NULLIF($2, ''), payout_id), last_error = NULLIF($3, ''), updated_at = $4
+		WHERE id = $5 AND status = $6`, to, payoutID, lastError, time.Now(), id, from)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	updated, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return updated == 1, nil
+}
+
+func scanScheduledPayout(row rowScanner) (*model.ScheduledPayout, error) {
+	var (
+		p      model.ScheduledPayout
+		payout []byte
+	)
+
+	err := row.Scan(&p.ID, &p.JobID, &payout, &p.IdempotenceKey, &p.ExecuteAt, &p.Status, &p.PayoutID, &p.LastError, &p.StartedAt, &p.CreatedAt, &p.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(payout, &p.Payout); err != nil {
+		return nil, err
+	}
+
+	return &p, nil
+}
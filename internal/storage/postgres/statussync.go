@@ -0,0 +1,120 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	yoomodel "github.com/eclipsemode/go-yookassa-sdk/yookassa/model"
+	"github.com/imperatorofdwelling/payment-svc/internal/domain/model"
+	"go.uber.org/zap"
+	"time"
+)
+
+type IStatusSyncRepo interface {
+	EnqueueStatusSync(ctx context.Context, transactionID string, transactionType yoomodel.TransactionType, nextAttemptAt time.Time) error
+	ClaimStatusSync(ctx context.Context, lease time.Duration) (*model.StatusSyncItem, error)
+	RescheduleStatusSync(ctx context.Context, transactionID string, nextAttemptAt time.Time, lastError string) error
+	FailStatusSync(ctx context.Context, transactionID string, lastError string) error
+	DeleteStatusSync(ctx context.Context, transactionID string) error
+}
+
+const statusSyncColumns = `transaction_id, transaction_type, state, attempts, next_attempt_at, COALESCE(last_error, ''), created_at, updated_at`
+
+type StatusSyncRepo struct {
+	db  *sql.DB
+	log *zap.SugaredLogger
+}
+
+func NewStatusSyncRepo(db *sql.DB, log *zap.SugaredLogger) *StatusSyncRepo {
+	return &StatusSyncRepo{db, log}
+}
+
+// EnqueueStatusSync adds the transaction to the queue. A transaction already in the queue keeps its schedule,
+// one that was given up on is polled again from scratch.
+func (r *StatusSyncRepo) EnqueueStatusSync(ctx context.Context, transactionID string, transactionType yoomodel.TransactionType, nextAttemptAt time.Time) error {
+	const op = "repo.postgres.statussync.EnqueueStatusSync"
+
+	_, err := r.db.ExecContext(ctx, `INSERT INTO status_sync_queue(transaction_id, transaction_type, state, attempts, next_attempt_at, created_at, updated_at)
+		VALUES ($1, $2, $3, 0, $4, $5, $5)
+		ON CONFLICT (transaction_id) DO UPDATE SET state = EXCLUDED.state, attempts = 0, next_attempt_at = EXCLUDED.next_attempt_at,
+			last_error = NULL, created_at = EXCLUDED.created_at, updated_at = EXCLUDED.updated_at
+		WHERE status_sync_queue.state = $6`,
+		transactionID, transactionType, model.StatusSyncPending, nextAttemptAt, time.Now(), model.StatusSyncFailed)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// ClaimStatusSync takes the most overdue pending item and moves its next attempt a lease ahead, so other workers
+// skip it while it is polled. An item whose worker died is claimed again once the lease is over.
+// It returns nil when no item is due.
+func (r *StatusSyncRepo) ClaimStatusSync(ctx context.Context, lease time.Duration) (*model.StatusSyncItem, error) {
+	const op = "repo.postgres.statussync.ClaimStatusSync"
+
+	now := time.Now()
+
+	item, err := scanStatusSyncItem(r.db.QueryRowContext(ctx, `UPDATE status_sync_queue SET attempts = attempts + 1, next_attempt_at = $1, updated_at = $2
+		WHERE transaction_id = (
+			SELECT transaction_id FROM status_sync_queue WHERE state = $3 AND next_attempt_at <= $2
+			ORDER BY next_attempt_at LIMIT 1 FOR UPDATE SKIP LOCKED
+		) RETURNING `+statusSyncColumns,
+		now.Add(lease), now, model.StatusSyncPending))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return item, nil
+}
+
+func (r *StatusSyncRepo) RescheduleStatusSync(ctx context.Context, transactionID string, nextAttemptAt time.Time, lastError string) error {
+	const op = "repo.postgres.statussync.RescheduleStatusSync"
+
+	_, err := r.db.ExecContext(ctx, `UPDATE status_sync_queue SET next_attempt_at = $1, last_error = NULLIF($2, ''), updated_at = $3 WHERE transaction_id = $4`,
+		nextAttemptAt, lastError, time.Now(), transactionID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *StatusSyncRepo) FailStatusSync(ctx context.Context, transactionID string, lastError string) error {
+	const op = "repo.postgres.statussync.FailStatusSync"
+
+	_, err := r.db.ExecContext(ctx, `UPDATE status_sync_queue SET state = $1, last_error = NULLIF($2, ''), updated_at = $3 WHERE transaction_id = $4`,
+		model.StatusSyncFailed, lastError, time.Now(), transactionID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *StatusSyncRepo) DeleteStatusSync(ctx context.Context, transactionID string) error {
+	const op = "repo.postgres.statussync.DeleteStatusSync"
+
+	_, err := r.db.ExecContext(ctx, `DELETE FROM status_sync_queue WHERE transaction_id = $1`, transactionID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func scanStatusSyncItem(row rowScanner) (*model.StatusSyncItem, error) {
+	var item model.StatusSyncItem
+
+	err := row.Scan(&item.TransactionID, &item.TransactionType, &item.State, &item.Attempts, &item.NextAttemptAt,
+		&item.LastError, &item.CreatedAt, &item.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &item, nil
+}
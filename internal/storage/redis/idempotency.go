@@ -0,0 +1,127 @@
+package redis
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/imperatorofdwelling/payment-svc/internal/domain/model"
+	"github.com/redis/go-redis/v9"
+	"time"
+)
+
+type IIdempotencyRepo interface {
+	Start(key, fingerprint string, ttl time.Duration) (*model.IdempotentRequest, bool, error)
+	Complete(key string, request *model.IdempotentRequest, ttl time.Duration) error
+	Release(key string, request *model.IdempotentRequest) error
+}
+
+const IdempotencyTable = "idempotency"
+
+var ErrIdempotentRequestLost = errors.New("idempotent request expired and its key was taken by another request")
+
+// completeScript only replaces the key while it still holds the in progress request, so a request whose key
+// expired cannot overwrite the key taken by the next request. Release uses releaseScript of the locks likewise.
+var completeScript = redis.NewScript(`if redis.call("GET", KEYS[1]) == ARGV[1] then return redis.call("SET", KEYS[1], ARGV[2], "PX", ARGV[3]) else return false end`)
+
+type IdempotencyRepo struct {
+	rdb *redis.Client
+}
+
+func NewIdempotencyRepo(rdb *redis.Client) *IdempotencyRepo {
+	return &IdempotencyRepo{rdb: rdb}
+}
+
+// Start stores the request under the key as in progress for ttl. When the key is already taken it returns
+// false with the stored request instead.
+func (r *IdempotencyRepo) Start(key, fingerprint string, ttl time.Duration) (*model.IdempotentRequest, bool, error) {
+	const op = "redis.idempotency.Start"
+
+	request := &model.IdempotentRequest{Fingerprint: fingerprint, Owner: uuid.NewString()}
+
+	data, err := inProgress(request)
+	if err != nil {
+		return nil, false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	for {
+		ok, err := r.rdb.SetNX(ctx, r.getKey(key), data, ttl).Result()
+		if err != nil {
+			return nil, false, fmt.Errorf("%s: %w", op, err)
+		}
+
+		if ok {
+			return request, true, nil
+		}
+
+		stored, err := r.rdb.Get(ctx, r.getKey(key)).Bytes()
+		if errors.Is(err, redis.Nil) {
+			// The stored request expired or was released in between, try to take the key again.
+			continue
+		}
+		if err != nil {
+			return nil, false, fmt.Errorf("%s: %w", op, err)
+		}
+
+		var existing model.IdempotentRequest
+
+		if err := json.Unmarshal(stored, &existing); err != nil {
+			return nil, false, fmt.Errorf("%s: %w", op, err)
+		}
+
+		return &existing, false, nil
+	}
+}
+
+// Complete stores the request with its response for ttl, replacing the in progress one. It returns
+// ErrIdempotentRequestLost when the key does not hold the request started by Start anymore.
+func (r *IdempotencyRepo) Complete(key string, request *model.IdempotentRequest, ttl time.Duration) error {
+	const op = "redis.idempotency.Complete"
+
+	started, err := inProgress(request)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	data, err := json.Marshal(request)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = completeScript.Run(ctx, r.rdb, []string{r.getKey(key)}, started, data, ttl.Milliseconds()).Err()
+	if errors.Is(err, redis.Nil) {
+		return fmt.Errorf("%s: %w", op, ErrIdempotentRequestLost)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Release forgets the key so that the next request with it is handled from scratch. The key is kept
+// when it does not hold the request started by Start anymore.
+func (r *IdempotencyRepo) Release(key string, request *model.IdempotentRequest) error {
+	const op = "redis.idempotency.Release"
+
+	started, err := inProgress(request)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = releaseScript.Run(ctx, r.rdb, []string{r.getKey(key)}, started).Err()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// inProgress is how the request is stored while it is handled, the scripts compare it with the stored value.
+func inProgress(request *model.IdempotentRequest) ([]byte, error) {
+	return json.Marshal(model.IdempotentRequest{Fingerprint: request.Fingerprint, Owner: request.Owner})
+}
+
+func (r *IdempotencyRepo) getKey(key string) string {
+	return IdempotencyTable + ":" + key
+}
@@ -0,0 +1,69 @@
+package redis
+
+import (
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"time"
+)
+
+type ILockRepo interface {
+	Acquire(key, token string, ttl time.Duration) (bool, error)
+	Extend(key, token string, ttl time.Duration) (bool, error)
+	Release(key, token string) error
+}
+
+const LockTable = "locks"
+
+// extendScript and releaseScript only touch the lock while it still holds the owner token,
+// so an owner whose lock expired cannot extend or delete the lock of the next owner.
+var (
+	extendScript  = redis.NewScript(`if redis.call("GET", KEYS[1]) == ARGV[1] then return redis.call("PEXPIRE", KEYS[1], ARGV[2]) else return 0 end`)
+	releaseScript = redis.NewScript(`if redis.call("GET", KEYS[1]) == ARGV[1] then return redis.call("DEL", KEYS[1]) else return 0 end`)
+)
+
+type LockRepo struct {
+	rdb *redis.Client
+}
+
+func NewLockRepo(rdb *redis.Client) *LockRepo {
+	return &LockRepo{rdb: rdb}
+}
+
+// Acquire takes the lock for ttl. It returns false when the lock is held by someone else.
+func (r *LockRepo) Acquire(key, token string, ttl time.Duration) (bool, error) {
+	const op = "redis.lock.Acquire"
+
+	ok, err := r.rdb.SetNX(ctx, r.getKey(key), token, ttl).Result()
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return ok, nil
+}
+
+// Extend sets a new ttl of the lock. It returns false when the lock is not held with the token anymore.
+func (r *LockRepo) Extend(key, token string, ttl time.Duration) (bool, error) {
+	const op = "redis.lock.Extend"
+
+	res, err := extendScript.Run(ctx, r.rdb, []string{r.getKey(key)}, token, ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return res == 1, nil
+}
+
+func (r *LockRepo) Release(key, token string) error {
+	const op = "redis.lock.Release"
+
+	err := releaseScript.Run(ctx, r.rdb, []string{r.getKey(key)}, token).Err()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *LockRepo) getKey(key string) string {
+	return LockTable + ":" + key
+}
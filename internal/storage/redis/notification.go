@@ -0,0 +1,51 @@
+package redis
+
+import (
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"time"
+)
+
+type INotificationRepo interface {
+	Acquire(key string, ttl time.Duration) (bool, error)
+	Release(key string) error
+}
+
+const NotificationTable = "notifications"
+
+type NotificationRepo struct {
+	rdb *redis.Client
+}
+
+func NewNotificationRepo(rdb *redis.Client) *NotificationRepo {
+	return &NotificationRepo{rdb: rdb}
+}
+
+// Acquire marks the notification as being processed. It returns false when the same notification
+// was already delivered within ttl.
+func (r *NotificationRepo) Acquire(key string, ttl time.Duration) (bool, error) {
+	const op = "redis.notification.Acquire"
+
+	ok, err := r.rdb.SetNX(ctx, r.getKey(key), time.Now().Unix(), ttl).Result()
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return ok, nil
+}
+
+// Release forgets the notification so that a redelivery is processed again.
+func (r *NotificationRepo) Release(key string) error {
+	const op = "redis.notification.Release"
+
+	err := r.rdb.Del(ctx, r.getKey(key)).Err()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *NotificationRepo) getKey(key string) string {
+	return NotificationTable + ":" + key
+}
@@ -5,25 +5,40 @@ import (
 	"io"
 	"log"
 	"net/http"
+	"strings"
 )
 
 type ErrType string
 
 var (
-	ValidationError        ErrType = "validation_error"
-	AuthorizationError     ErrType = "authorization_error"
-	DecodeBodyError        ErrType = "decode_body_error"
-	GettingHeaderDataError ErrType = "getting_header_data_error"
-	ExternalApiError       ErrType = "external_api_error"
-	InternalApiError       ErrType = "internal_api_error"
-	UnmarshallingError     ErrType = "unmarshalling_error"
-	ParseError             ErrType = "parse_error"
+	ValidationError          ErrType = "validation_error"
+	AuthorizationError       ErrType = "authorization_error"
+	DecodeBodyError          ErrType = "decode_body_error"
+	GettingHeaderDataError   ErrType = "getting_header_data_error"
+	ExternalApiError         ErrType = "external_api_error"
+	InternalApiError         ErrType = "internal_api_error"
+	UnmarshallingError       ErrType = "unmarshalling_error"
+	ParseError               ErrType = "parse_error"
+	NotFoundError            ErrType = "not_found_error"
+	ConflictError            ErrType = "conflict_error"
+	InProgressError          ErrType = "in_progress_error"
+	ProviderUnavailableError ErrType = "provider_unavailable_error"
 )
 
 type ErrResponse struct {
-	Type ErrType `json:"type"`
-	Code int     `json:"code"`
-	Msg  string  `json:"msg"`
+	Type   ErrType      `json:"type"`
+	Code   int          `json:"code"`
+	Msg    string       `json:"msg"`
+	Fields []FieldError `json:"fields,omitempty"`
+}
+
+// FieldError describes one failed validation rule. Field is the path of the body key, e.g. amount.value,
+// Tag and Param are the rule with its argument and Message is meant to be shown to the user.
+type FieldError struct {
+	Field   string `json:"field"`
+	Tag     string `json:"tag"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
 }
 
 type Response struct {
@@ -69,3 +84,30 @@ func WriteError(w http.ResponseWriter, status http.ConnState, errMsg string, err
 		log.Println(err.Error())
 	}
 }
+
+// WriteFieldErrors responds with a validation error listing the failed fields. Msg joins their messages
+// for clients that do not read fields.
+func WriteFieldErrors(w http.ResponseWriter, status http.ConnState, fields []FieldError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(int(status))
+
+	messages := make([]string, 0, len(fields))
+	for _, f := range fields {
+		messages = append(messages, f.Message)
+	}
+
+	resp := Response{
+		Data: nil,
+		Err: &ErrResponse{
+			Type:   ValidationError,
+			Code:   int(status),
+			Msg:    strings.Join(messages, "; "),
+			Fields: fields,
+		},
+	}
+
+	err := json.NewEncoder(w).Encode(resp)
+	if err != nil {
+		log.Println(err.Error())
+	}
+}